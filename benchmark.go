@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"1brc-go/brc"
+)
+
+// Ways to handle the page cache between the runs of a benchmark.
+const (
+	cacheKeep = "keep" // leave it alone, later runs read the file from memory
+	cacheWarm = "warm" // read the whole file before each solution
+	cacheDrop = "drop" // drop it before every run, needs root on linux
+)
+
+type benchmarkOptions struct {
+	warmup int // runs of each solution before measuring, verified but not timed
+	runs   int // measured runs of each solution
+	cache  string
+}
+
+// benchmarkReport is the machine readable outcome of a benchmark, written
+// by -report and appended to the history by -history.
+type benchmarkReport struct {
+	Time      time.Time        `json:"time"`
+	File      string           `json:"file"`
+	FileSize  int64            `json:"file_size"`
+	Rows      int64            `json:"rows"`
+	Warmup    int              `json:"warmup"`
+	Runs      int              `json:"runs"`
+	Cache     string           `json:"cache"`
+	Solutions []solutionReport `json:"solutions"`
+
+	Commit     string `json:"commit,omitempty"`
+	GoVersion  string `json:"go_version"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	CPU        string `json:"cpu,omitempty"`
+}
+
+// solutionReport holds the timings of a solution, all durations in
+// nanoseconds. CILow and CIHigh bound the 95% confidence interval of the mean.
+type solutionReport struct {
+	Name       string          `json:"name"`
+	Failed     string          `json:"failed,omitempty"` // why its output was rejected
+	Timings    []time.Duration `json:"timings_ns,omitempty"`
+	Min        time.Duration   `json:"min_ns"`
+	Median     time.Duration   `json:"median_ns"`
+	Mean       time.Duration   `json:"mean_ns"`
+	P95        time.Duration   `json:"p95_ns"`
+	Stddev     time.Duration   `json:"stddev_ns"`
+	CILow      time.Duration   `json:"ci_low_ns"`
+	CIHigh     time.Duration   `json:"ci_high_ns"`
+	RowsPerSec float64         `json:"rows_per_sec"`
+	MBPerSec   float64         `json:"mb_per_sec"`
+	Memory     memoryUsage     `json:"memory"`
+}
+
+// tQuantiles are the two-sided 95% quantiles of Student's t distribution
+// for 1 to 30 degrees of freedom, the normal 1.96 is used above.
+var tQuantiles = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func tQuantile(degrees int) float64 {
+	if degrees < 1 {
+		return math.NaN()
+	}
+	if degrees > len(tQuantiles) {
+		return 1.96
+	}
+	return tQuantiles[degrees-1]
+}
+
+// summarize fills in the statistics of the timings of report.
+func (report *solutionReport) summarize(timings []time.Duration, rows, fileSize int64) {
+	report.Timings = timings
+	sorted := slices.Clone(timings)
+	slices.Sort(sorted)
+	n := len(sorted)
+
+	var sum float64
+	for _, t := range sorted {
+		sum += float64(t)
+	}
+	mean := sum / float64(n)
+
+	var squares float64
+	for _, t := range sorted {
+		squares += (float64(t) - mean) * (float64(t) - mean)
+	}
+	stddev := 0.0
+	if n > 1 {
+		stddev = math.Sqrt(squares / float64(n-1))
+	}
+	margin := 0.0
+	if n > 1 {
+		margin = tQuantile(n-1) * stddev / math.Sqrt(float64(n))
+	}
+
+	report.Min = sorted[0]
+	report.Median = (sorted[(n-1)/2] + sorted[n/2]) / 2
+	report.Mean = time.Duration(mean)
+	report.P95 = sorted[int(math.Ceil(0.95*float64(n)))-1] // nearest rank
+	report.Stddev = time.Duration(stddev)
+	report.CILow = time.Duration(mean - margin)
+	report.CIHigh = time.Duration(mean + margin)
+	report.RowsPerSec = float64(rows) / report.Mean.Seconds()
+	report.MBPerSec = float64(fileSize) / (1 << 20) / report.Mean.Seconds()
+}
+
+// prepareCache warms or drops the page cache for filePath.
+func prepareCache(cache, filePath string) error {
+	switch cache {
+	case cacheWarm:
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(io.Discard, file)
+		return err
+	case cacheDrop:
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		err = file.Sync()
+		file.Close()
+		if err != nil {
+			return err
+		}
+		// 1 drops the clean page cache, there is no portable way to do it
+		if err := os.WriteFile("/proc/sys/vm/drop_caches", []byte("1"), 0); err != nil {
+			return fmt.Errorf("dropping the page cache needs root on linux: %w", err)
+		}
+	}
+	return nil
+}
+
+// verifyResult compares the result of a solution against the reference
+// result and reports the first station (in sorted order) whose values differ.
+func verifyResult(want, got brc.Result) error {
+	if want.Rejected != got.Rejected {
+		return fmt.Errorf("rejected lines: want %d, got %d", want.Rejected, got.Rejected)
+	}
+
+	i, j := 0, 0
+	for i < len(want.Stations) || j < len(got.Stations) {
+		switch {
+		case j == len(got.Stations) ||
+			(i < len(want.Stations) && want.Stations[i].Station < got.Stations[j].Station):
+			return fmt.Errorf("station %q: want %s, got <missing>", want.Stations[i].Station, want.Stations[i])
+		case i == len(want.Stations) || got.Stations[j].Station < want.Stations[i].Station:
+			return fmt.Errorf("station %q: want <missing>, got %s", got.Stations[j].Station, got.Stations[j])
+		case !sameStats(want.Stations[i], got.Stations[j]):
+			w, g := want.Stations[i], got.Stations[j]
+			return fmt.Errorf("station %q: want %s count=%d sum=%d, got %s count=%d sum=%d",
+				w.Station, w, w.Count, w.Sum, g, g.Count, g.Sum)
+		}
+		i++
+		j++
+	}
+	return nil
+}
+
+// sameStats compares the aggregates of a station exactly, a dropped or
+// double counted line changing the count and sum even when the rounded mean
+// stays the same.
+func sameStats(want, got brc.StationStats) bool {
+	return want.Min == got.Min && want.Max == got.Max && want.Sum == got.Sum && want.Count == got.Count
+}
+
+// printWorkers prints how long each worker of the best run was busy, the
+// gap between the fastest and the slowest one being time lost to stragglers.
+func printWorkers(workers []brc.WorkerStats) {
+	fastest, slowest := workers[0].Busy, workers[0].Busy
+	fmt.Fprintf(os.Stdout, "    workers:")
+	for _, w := range workers {
+		fmt.Fprintf(os.Stdout, " %v (%.1fMB)", w.Busy.Round(time.Microsecond), float64(w.Bytes)/(1<<20))
+		fastest = min(fastest, w.Busy)
+		slowest = max(slowest, w.Busy)
+	}
+	fmt.Fprintf(os.Stdout, " - slowest/fastest: %.2fx\n", float64(slowest)/float64(max(fastest, 1)))
+}
+
+// printStats prints the statistics of a solution compared to the first one.
+func printStats(report, first solutionReport) {
+	round := func(d time.Duration) time.Duration { return d.Round(time.Microsecond) }
+	fmt.Fprintf(os.Stdout, "    min %v, median %v, mean %v (95%% CI %v-%v), p95 %v, stddev %v\n",
+		round(report.Min), round(report.Median), round(report.Mean), round(report.CILow), round(report.CIHigh),
+		round(report.P95), round(report.Stddev))
+	fmt.Fprintf(os.Stdout, "    %.1fM rows/s, %.1f MB/s, %.2fx faster than %s by median\n",
+		report.RowsPerSec/1e6, report.MBPerSec, float64(first.Median)/float64(report.Median), first.Name)
+	fmt.Fprintf(os.Stdout, "    %v\n", report.Memory)
+}
+
+// benchmark runs every solution options.warmup times and then measures
+// options.runs runs, verifying every output against the first solution.
+func benchmark(ctx context.Context, input brc.Input, options benchmarkOptions) (benchmarkReport, error) {
+	stat, err := os.Stat(input.FilePath)
+	if err != nil {
+		return benchmarkReport{}, err
+	}
+
+	solvers := brc.Solvers()
+	if err := prepareCache(options.cache, input.FilePath); err != nil {
+		return benchmarkReport{}, err
+	}
+	reference, err := solvers[0].Solve(ctx, input)
+	if err != nil {
+		return benchmarkReport{}, err
+	}
+
+	report := benchmarkReport{
+		Time:     time.Now().UTC(),
+		File:     input.FilePath,
+		FileSize: stat.Size(),
+		Rows:     reference.Rejected,
+		Warmup:   options.warmup,
+		Runs:     options.runs,
+		Cache:    options.cache,
+	}
+	for _, station := range reference.Stations {
+		report.Rows += station.Count
+	}
+	report.environment()
+
+	var failed []string
+	for _, solver := range solvers {
+		fmt.Printf("%s: ", solver.Name())
+		solution := solutionReport{Name: solver.Name()}
+
+		if options.cache == cacheWarm {
+			if err := prepareCache(options.cache, input.FilePath); err != nil {
+				return benchmarkReport{}, err
+			}
+		}
+
+		var timings []time.Duration
+		var bestWorkers []brc.WorkerStats
+		var memory memoryUsage
+		var mismatch error
+		for trial := 0; trial < options.warmup+options.runs; trial++ {
+			if options.cache == cacheDrop {
+				if err := prepareCache(options.cache, input.FilePath); err != nil {
+					return benchmarkReport{}, err
+				}
+			}
+
+			measure := measureMemory()
+			start := time.Now()
+			result, err := solver.Solve(ctx, input)
+			if err != nil {
+				return benchmarkReport{}, err
+			}
+			elapsed := time.Since(start)
+			usage := measure(1)
+
+			// never report a speedup for a wrong answer
+			if mismatch = verifyResult(reference, result); mismatch != nil {
+				break
+			}
+			if trial < options.warmup {
+				continue
+			}
+			memory.AllocBytes += usage.AllocBytes
+			memory.Allocs += usage.Allocs
+			memory.GCCycles += usage.GCCycles
+			memory.PeakRSS = max(memory.PeakRSS, usage.PeakRSS)
+			fmt.Fprintf(os.Stdout, " %v", elapsed)
+			if len(timings) == 0 || elapsed < slices.Min(timings) {
+				bestWorkers = result.Workers
+			}
+			timings = append(timings, elapsed)
+		}
+
+		if mismatch != nil {
+			fmt.Fprintf(os.Stdout, " - FAILED: output differs from %s: %v\n", solvers[0].Name(), mismatch)
+			solution.Failed = mismatch.Error()
+			report.Solutions = append(report.Solutions, solution)
+			failed = append(failed, solver.Name())
+			continue
+		}
+
+		solution.summarize(timings, report.Rows, report.FileSize)
+		memory.AllocBytes /= uint64(len(timings))
+		memory.Allocs /= uint64(len(timings))
+		solution.Memory = memory
+		report.Solutions = append(report.Solutions, solution)
+		fmt.Fprintln(os.Stdout)
+		printStats(solution, report.Solutions[0])
+		if len(bestWorkers) > 0 {
+			printWorkers(bestWorkers)
+		}
+	}
+
+	if len(failed) > 0 {
+		return report, fmt.Errorf("output verification failed for %s", strings.Join(failed, ", "))
+	}
+	return report, nil
+}
+
+// writeReport writes report as indented JSON to reportPath.
+func writeReport(reportPath string, report benchmarkReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(reportPath, append(data, '\n'), 0666)
+}
@@ -0,0 +1,206 @@
+package brc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// benchmarkData returns the same generated measurements on every run,
+// ending with a newline.
+func benchmarkData(lines, stations int) []byte {
+	return seededData(1, lines, stations)
+}
+
+func seededData(seed int64, lines, stations int) []byte {
+	data := randomMeasurements(rand.New(rand.NewSource(seed)), lines, stations)
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	return data
+}
+
+// BenchmarkSolvers runs every solver on in-memory data through
+// Input.Reader, so it does not depend on the disk or the page cache.
+func BenchmarkSolvers(b *testing.B) {
+	data := benchmarkData(500_000, 413)
+	for _, solver := range Solvers() {
+		b.Run(solver.Name(), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				_, err := solver.Solve(context.Background(), Input{Reader: bytes.NewReader(data)})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSharedAffixes runs the solvers of the station table on names that
+// only differ in the middle, which must not hash to the same slots.
+func BenchmarkSharedAffixes(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	names := sharedAffixNames(10_000)
+	var data []byte
+	for i := 0; i < 200_000; i++ {
+		data = fmt.Appendf(data, "%s;%.1f\n", names[r.Intn(len(names))], float64(r.Intn(1999)-999)/10)
+	}
+	for _, name := range []string{"solution5", "solution6"} {
+		solver, _ := Lookup(name)
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := solver.Solve(context.Background(), Input{Reader: bytes.NewReader(data)}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// benchmarkLines returns the lines of the generated measurements, each one
+// ending with its newline.
+func benchmarkLines(lines, stations int) [][]byte {
+	data := benchmarkData(lines, stations)
+	return bytes.SplitAfter(data[:len(data)-1], []byte("\n"))
+}
+
+// hashStation is the FNV-1a loop processLinesS5 runs inline, returning the
+// hash of the station name at the start of chunk and the index of the ';'
+// or '\n' ending it.
+func hashStation(chunk []byte) (uint64, int) {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	hash := uint64(offset64)
+	i := 0
+	for ; i < len(chunk); i++ {
+		c := chunk[i]
+		if c == ';' || c == '\n' {
+			break
+		}
+		hash ^= uint64(c)
+		hash *= prime64
+	}
+	return hash, i
+}
+
+// parseTemperatureS5 is the branchy parser processLinesS5 runs inline,
+// returning the temperature at the start of b in tenths of a degree and its
+// length including the newline.
+func parseTemperatureS5(b []byte) (int32, int) {
+	negative := false
+	idx := 0
+
+	if b[idx] == '-' {
+		negative = true
+		idx++
+	}
+	temp := int32(b[idx] - '0')
+	idx++
+	if b[idx] != '.' {
+		temp = temp*10 + int32(b[idx]-'0')
+		idx++
+	}
+	idx++
+	temp = temp*10 + int32(b[idx]-'0')
+	idx += 2
+	if negative {
+		temp = -temp
+	}
+	return temp, idx
+}
+
+var hashSink uint64
+
+func BenchmarkHash(b *testing.B) {
+	lines := benchmarkLines(10_000, 413)
+	b.Run("fnv", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			hash, _ := hashStation(lines[i%len(lines)])
+			hashSink += hash
+		}
+	})
+	b.Run("nameHash", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			line := lines[i%len(lines)]
+			hashSink += nameHash(line, bytes.IndexByte(line, ';'))
+		}
+	})
+}
+
+var tempSink int32
+
+func BenchmarkParseTemperature(b *testing.B) {
+	// the temperatures run on to the end of the data like in a block, so
+	// that loadWord reads 8 bytes at once
+	data := benchmarkData(10_000, 413)
+	var temps [][]byte
+	for rest := data; len(rest) > 0; {
+		temp := rest[bytes.IndexByte(rest, ';')+1:]
+		temps = append(temps, temp)
+		rest = temp[bytes.IndexByte(temp, '\n')+1:]
+	}
+	b.Run("branchy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			temp, _ := parseTemperatureS5(temps[i%len(temps)])
+			tempSink += temp
+		}
+	})
+	b.Run("swar", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			temp, _ := parseTemperatureWord(loadWord(temps[i%len(temps)]))
+			tempSink += temp
+		}
+	})
+}
+
+func BenchmarkStationTableGet(b *testing.B) {
+	for _, stations := range []int{413, 10_000} {
+		b.Run(fmt.Sprintf("%d stations", stations), func(b *testing.B) {
+			var names [][]byte
+			var hashes []uint64
+			for _, line := range benchmarkLines(50_000, stations) {
+				hash, i := hashStation(line)
+				names = append(names, line[:i])
+				hashes = append(hashes, hash)
+			}
+			table := newStationTable[s5WeatherStationStats](defaultTableBuckets)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				j := i % len(names)
+				stat, _ := table.get(hashes[j], names[j])
+				stat.count++
+			}
+		})
+	}
+}
+
+func BenchmarkStationTableMerge(b *testing.B) {
+	// the tables of two workers that saw every station
+	var workers []*stationTable[s5WeatherStationStats]
+	for seed := int64(1); seed <= 2; seed++ {
+		table := newStationTable[s5WeatherStationStats](defaultTableBuckets)
+		data := seededData(seed, 50_000, 10_000)
+		if err := processLinesS5(block{data: data}, table, &lineRejecter{policy: PolicySkip}, newS5Options(Input{}, fnvHash)); err != nil {
+			b.Fatal(err)
+		}
+		workers = append(workers, table)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		merged := newStationTable[s5WeatherStationStats](defaultTableBuckets)
+		b.StartTimer()
+		for _, table := range workers {
+			merged.merge(table, mergeS5)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package brc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic bytes at the start of the supported compressed formats.
+var (
+	gzipMagic  = []byte{0x1f, 0x8b, 0x08}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// isBzip2 checks for "BZh", the block size digit and the block magic (BCD pi),
+// as station names could start with "BZh" too.
+func isBzip2(header []byte) bool {
+	return len(header) >= 10 && bytes.HasPrefix(header, bzip2Magic) &&
+		header[3] >= '1' && header[3] <= '9' &&
+		bytes.Equal(header[4:10], []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59})
+}
+
+// decompressInput sniffs the magic bytes of input and, when it is gzip,
+// bzip2 or zstd compressed, returns an Input reading the decompressed
+// stream. Compressed data cannot be split or mapped, so the parallel
+// solutions read it like stdin. An uncompressed file is returned unchanged
+// so it can still be split into chunks. closeInput releases the file and
+// decoders opened for the returned Input.
+func decompressInput(input Input, workers int) (_ Input, closeInput func() error, err error) {
+	closeInput = func() error { return nil }
+
+	r := input.Reader
+	if r == nil {
+		file, err := os.Open(input.FilePath)
+		if err != nil {
+			return Input{}, nil, err
+		}
+		defer func() {
+			if err != nil || input.Reader == nil {
+				file.Close()
+			}
+		}()
+		r = file
+		closeInput = file.Close
+	}
+
+	buffered := bufio.NewReaderSize(r, blockSize)
+	header, err := buffered.Peek(18)
+	if err != nil && err != io.EOF {
+		return Input{}, nil, err
+	}
+
+	var decompressed io.Reader
+	switch {
+	case bytes.HasPrefix(header, gzipMagic) && isBGZF(header):
+		bgzf := newBGZFReader(buffered, workers)
+		closeFile := closeInput
+		closeInput = func() error {
+			bgzf.Close()
+			return closeFile()
+		}
+		decompressed = bgzf
+	case bytes.HasPrefix(header, gzipMagic):
+		decompressed, err = gzip.NewReader(buffered) // reads all members of multi-member files
+		if err != nil {
+			return Input{}, nil, err
+		}
+	case isBzip2(header):
+		decompressed = bzip2.NewReader(buffered)
+	case bytes.HasPrefix(header, zstdMagic):
+		decoder, err := zstd.NewReader(buffered, zstd.WithDecoderConcurrency(workers))
+		if err != nil {
+			return Input{}, nil, err
+		}
+		closeFile := closeInput
+		closeInput = func() error {
+			decoder.Close()
+			return closeFile()
+		}
+		decompressed = decoder
+	case input.Reader == nil:
+		return input, func() error { return nil }, nil // plain file, keep splitting it
+	default:
+		input.Reader = buffered // keep the peeked bytes
+		return input, closeInput, nil
+	}
+
+	input.Reader = decompressed
+	input.IO = IOBuffered
+	return input, closeInput, nil
+}
+
+// isBGZF reports whether a gzip header carries the "BC" extra field of
+// BGZF (blocked gzip as written by bgzip), which stores the compressed size
+// of every member.
+func isBGZF(header []byte) bool {
+	const flagExtra = 0x04
+	return len(header) >= 18 && header[3]&flagExtra != 0 &&
+		binary.LittleEndian.Uint16(header[10:]) >= 6 &&
+		header[12] == 'B' && header[13] == 'C' && binary.LittleEndian.Uint16(header[14:]) == 2
+}
+
+type bgzfBlock struct {
+	data []byte
+	err  error
+}
+
+// bgzfReader inflates the independent members of a BGZF file in parallel
+// and returns their data in file order.
+type bgzfReader struct {
+	blocks  chan chan bgzfBlock // one channel per member, in file order
+	done    chan struct{}
+	current []byte
+	err     error
+}
+
+func newBGZFReader(r io.Reader, workers int) *bgzfReader {
+	b := &bgzfReader{
+		blocks: make(chan chan bgzfBlock, 4*workers), // bounds the members inflated ahead
+		done:   make(chan struct{}),
+	}
+
+	type job struct {
+		member []byte
+		result chan bgzfBlock
+	}
+	jobs := make(chan job, workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for j := range jobs {
+				data, err := inflateMember(j.member)
+				j.result <- bgzfBlock{data, err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(b.blocks)
+		defer close(jobs)
+		for {
+			result := make(chan bgzfBlock, 1)
+			member, err := readBGZFMember(r)
+			if err == io.EOF {
+				return
+			}
+			if err == nil {
+				select {
+				case jobs <- job{member, result}:
+				case <-b.done:
+					return
+				}
+			} else {
+				result <- bgzfBlock{err: err} // reported in order, after the previous members
+			}
+
+			select {
+			case b.blocks <- result:
+			case <-b.done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return b
+}
+
+// readBGZFMember reads a whole gzip member using the size stored in its header.
+func readBGZFMember(r io.Reader) ([]byte, error) {
+	header := make([]byte, 18)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("bgzf: truncated member header")
+		}
+		return nil, err
+	}
+	if !bytes.HasPrefix(header, gzipMagic) || !isBGZF(header) {
+		return nil, errors.New("bgzf: invalid member header")
+	}
+
+	size := int(binary.LittleEndian.Uint16(header[16:])) + 1
+	if size < len(header) {
+		return nil, fmt.Errorf("bgzf: invalid member size %d", size)
+	}
+	member := make([]byte, size)
+	copy(member, header)
+	if _, err := io.ReadFull(r, member[len(header):]); err != nil {
+		return nil, fmt.Errorf("bgzf: truncated member: %w", err)
+	}
+	return member, nil
+}
+
+func inflateMember(member []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(member))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+func (b *bgzfReader) Read(p []byte) (int, error) {
+	for len(b.current) == 0 {
+		if b.err != nil {
+			return 0, b.err
+		}
+		result, ok := <-b.blocks
+		if !ok {
+			b.err = io.EOF
+			continue
+		}
+		block := <-result
+		b.current, b.err = block.data, block.err
+	}
+
+	n := copy(p, b.current)
+	b.current = b.current[n:]
+	return n, nil
+}
+
+// Close stops inflating members ahead of the reader.
+func (b *bgzfReader) Close() {
+	close(b.done)
+}
@@ -0,0 +1,160 @@
+package brc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// gzipMembers compresses each piece of data into its own gzip member, with
+// the "BC" extra field of BGZF when bgzf is set.
+func gzipMembers(t *testing.T, pieces [][]byte, bgzf bool) []byte {
+	t.Helper()
+	var out []byte
+	for _, piece := range pieces {
+		var member bytes.Buffer
+		writer := gzip.NewWriter(&member)
+		if bgzf {
+			writer.Header.Extra = []byte{'B', 'C', 2, 0, 0, 0} // the size is set once compressed
+		}
+		if _, err := writer.Write(piece); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		data := member.Bytes()
+		if bgzf {
+			binary.LittleEndian.PutUint16(data[16:], uint16(len(data)-1))
+		}
+		out = append(out, data...)
+	}
+	return out
+}
+
+// zstdFrames compresses each piece of data into its own zstd frame.
+func zstdFrames(t *testing.T, pieces [][]byte) []byte {
+	t.Helper()
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer encoder.Close()
+	var out []byte
+	for _, piece := range pieces {
+		out = encoder.EncodeAll(piece, out)
+	}
+	return out
+}
+
+// splitEvery cuts data every size bytes, in the middle of lines.
+func splitEvery(data []byte, size int) [][]byte {
+	var pieces [][]byte
+	for len(data) > size {
+		pieces = append(pieces, data[:size])
+		data = data[size:]
+	}
+	return append(pieces, data)
+}
+
+// TestDecompress checks every solver gives the golden result of a testdata
+// file compressed in each supported format.
+func TestDecompress(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "utf8_long_names.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "utf8_long_names.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the standard library has no bzip2 writer, the file was compressed
+	// with bzip2 -9
+	bzipped, err := os.ReadFile(filepath.Join("testdata", "utf8_long_names.txt.bz2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pieces := splitEvery(data, 4093)
+	formats := []struct {
+		name       string
+		compressed []byte
+	}{
+		{"gzip", gzipMembers(t, [][]byte{data}, false)},
+		{"multi-member gzip", gzipMembers(t, splitEvery(data, 40000), false)},
+		// many members inflated in parallel, with the empty end of file one
+		{"bgzf", gzipMembers(t, append(pieces, nil), true)},
+		{"bzip2", bzipped},
+		{"zstd", zstdFrames(t, [][]byte{data})},
+		{"zstd frames", zstdFrames(t, pieces)},
+	}
+	for _, format := range formats {
+		t.Run(format.name, func(t *testing.T) {
+			if got := isBGZF(format.compressed); got != (format.name == "bgzf") {
+				t.Fatalf("isBGZF = %v", got)
+			}
+			path := filepath.Join(t.TempDir(), "measurements")
+			if err := os.WriteFile(path, format.compressed, 0666); err != nil {
+				t.Fatal(err)
+			}
+			solveAll(t, path, func(t *testing.T, result Result) {
+				var got bytes.Buffer
+				if err := result.Write(&got); err != nil {
+					t.Fatal(err)
+				}
+				if got.String() != string(want) {
+					t.Errorf("got\n%.500s\nwant\n%.500s", got.String(), want)
+				}
+			})
+		})
+	}
+}
+
+func TestIsBzip2(t *testing.T) {
+	bzipped, err := os.ReadFile(filepath.Join("testdata", "utf8_long_names.txt.bz2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{string(bzipped[:18]), true},
+		{"BZh9;12.3\nOslo;1.0\n", false},
+		{"BZh;1.0\n", false},
+		{"BZh0\x31\x41\x59\x26\x53\x59", false},
+		{"BZh9\x31\x41\x59", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isBzip2([]byte(tt.header)); got != tt.want {
+			t.Errorf("isBzip2(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// TestBzip2LookingInput checks a plain file starting with the bzip2 magic
+// bytes is read as measurements.
+func TestBzip2LookingInput(t *testing.T) {
+	data := []byte("BZh9;12.3\nBZh;-1.0\nOslo;2.0\nBZh9;0.5\n")
+	path := filepath.Join(t.TempDir(), "measurements.txt")
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	want := naiveStats(t, data)
+	solveAll(t, path, func(t *testing.T, result Result) {
+		if len(result.Stations) != len(want) {
+			t.Fatalf("got %v, want %v", result.Stations, want)
+		}
+		for i, got := range result.Stations {
+			if w := want[i]; got.Station != w.Station || got.Min != w.Min || got.Max != w.Max || got.Sum != w.Sum || got.Count != w.Count {
+				t.Errorf("got %v, want %v", got, w)
+			}
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package brc
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Encoder writes a Result to an output in a specific format.
+type Encoder interface {
+	Encode(output io.Writer, result Result) error
+}
+
+// EncoderFunc adapts a function to the Encoder interface.
+type EncoderFunc func(output io.Writer, result Result) error
+
+func (f EncoderFunc) Encode(output io.Writer, result Result) error {
+	return f(output, result)
+}
+
+var encoders = map[string]Encoder{
+	"brace":  EncoderFunc(encodeBrace),
+	"json":   EncoderFunc(encodeJSON),
+	"ndjson": EncoderFunc(encodeNDJSON),
+	"csv":    EncoderFunc(encodeCSV),
+}
+
+// LookupEncoder returns the encoder registered for the given format.
+func LookupEncoder(format string) (Encoder, bool) {
+	e, ok := encoders[format]
+	return e, ok
+}
+
+// RegisterEncoder adds or replaces the encoder for a format.
+// It is not safe to call concurrently with the other encoder functions.
+func RegisterEncoder(format string, e Encoder) {
+	encoders[format] = e
+}
+
+// EncoderFormats returns the sorted names of all registered formats.
+func EncoderFormats() []string {
+	formats := make([]string, 0, len(encoders))
+	for format := range encoders {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// encodeBrace writes the challenge format "{station=min/mean/max, ...}".
+func encodeBrace(output io.Writer, result Result) error {
+	return result.Write(output)
+}
+
+// tenths is a temperature in tenths of a degree that marshals to a JSON
+// number with exactly one fractional digit, e.g. -12.3
+type tenths int64
+
+func (t tenths) MarshalJSON() ([]byte, error) {
+	return appendTenths(nil, int64(t)), nil
+}
+
+type jsonStationStats struct {
+	Station string `json:"station"`
+	Min     tenths `json:"min"`
+	Mean    tenths `json:"mean"`
+	Max     tenths `json:"max"`
+	Count   int64  `json:"count"`
+	Sum     tenths `json:"sum"`
+	// with Stats.Percentiles
+	Median *tenths `json:"median,omitempty"`
+	P90    *tenths `json:"p90,omitempty"`
+	P99    *tenths `json:"p99,omitempty"`
+	Mode   *tenths `json:"mode,omitempty"`
+	// with Stats.Variance
+	Variance *float64 `json:"variance,omitempty"`
+	Stddev   *float64 `json:"stddev,omitempty"`
+	// with Stats.Outliers
+	Below *int64 `json:"below,omitempty"`
+	Above *int64 `json:"above,omitempty"`
+}
+
+func newJSONStationStats(stat StationStats, stats Stats) jsonStationStats {
+	encoded := jsonStationStats{
+		Station: stat.Station,
+		Min:     tenths(stat.Min),
+		Mean:    tenths(stat.MeanTenths()),
+		Max:     tenths(stat.Max),
+		Count:   stat.Count,
+		Sum:     tenths(stat.Sum),
+	}
+	if stat.Histogram != nil {
+		p := stat.percentiles()
+		median, p90, p99, mode := tenths(p[0]), tenths(p[1]), tenths(p[2]), tenths(p[3])
+		encoded.Median, encoded.P90, encoded.P99, encoded.Mode = &median, &p90, &p99, &mode
+	}
+	if stats.Variance {
+		variance, stddev := stat.Variance(), stat.Stddev()
+		encoded.Variance, encoded.Stddev = &variance, &stddev
+	}
+	if stats.Outliers != nil {
+		encoded.Below, encoded.Above = &stat.Below, &stat.Above
+	}
+	return encoded
+}
+
+// encodeJSON writes a JSON array with one object per station.
+func encodeJSON(output io.Writer, result Result) error {
+	stations := make([]jsonStationStats, 0, len(result.Stations))
+	for _, stat := range result.Stations {
+		stations = append(stations, newJSONStationStats(stat, result.Stats))
+	}
+
+	encoder := json.NewEncoder(output)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(stations)
+}
+
+// encodeNDJSON writes one JSON object per station and line.
+func encodeNDJSON(output io.Writer, result Result) error {
+	writer := bufio.NewWriter(output)
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	for _, stat := range result.Stations {
+		if err := encoder.Encode(newJSONStationStats(stat, result.Stats)); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// encodeCSV writes a header followed by one record per station, quoting
+// station names that contain commas, quotes or newlines. The percentile,
+// variance and outlier columns are only written when computed.
+func encodeCSV(output io.Writer, result Result) error {
+	percentiles := len(result.Stations) > 0 && result.Stations[0].Histogram != nil
+	header := []string{"station", "min", "mean", "max", "count", "sum"}
+	if percentiles {
+		header = append(header, "median", "p90", "p99", "mode")
+	}
+	if result.Stats.Variance {
+		header = append(header, "variance", "stddev")
+	}
+	if result.Stats.Outliers != nil {
+		header = append(header, "below", "above")
+	}
+
+	writer := csv.NewWriter(output)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, stat := range result.Stations {
+		record := []string{
+			stat.Station,
+			string(appendTenths(nil, stat.Min)),
+			string(appendTenths(nil, stat.MeanTenths())),
+			string(appendTenths(nil, stat.Max)),
+			strconv.FormatInt(stat.Count, 10),
+			string(appendTenths(nil, stat.Sum)),
+		}
+		if percentiles {
+			for _, value := range stat.percentiles() {
+				record = append(record, string(appendTenths(nil, value)))
+			}
+		}
+		if result.Stats.Variance {
+			record = append(record,
+				strconv.FormatFloat(stat.Variance(), 'g', -1, 64),
+				strconv.FormatFloat(stat.Stddev(), 'g', -1, 64))
+		}
+		if result.Stats.Outliers != nil {
+			record = append(record, strconv.FormatInt(stat.Below, 10), strconv.FormatInt(stat.Above, 10))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Encode writes result to output in the given format.
+func Encode(output io.Writer, format string, result Result) error {
+	e, ok := LookupEncoder(format)
+	if !ok {
+		return fmt.Errorf("invalid format %q, should be one of %v", format, EncoderFormats())
+	}
+	return e.Encode(output, result)
+}
@@ -0,0 +1,130 @@
+package brc
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the encoders in testdata/encode")
+
+// escapedNames need quoting or escaping in some format.
+var escapedNames = []string{`Hamburg "Nord"`, "Paris, FR", "a=b", "New\nYork", "<Tag> & Co", "Zürich", "Tab\tStation"}
+
+// encoderResult returns stations named escapedNames, with the percentiles,
+// variance and outlier counts when extended.
+func encoderResult(extended bool) Result {
+	var result Result
+	for i, name := range escapedNames {
+		temps := []int32{int32(-15 * i), 40, 5*int32(i) - 1}
+		stat := StationStats{Station: name, Min: 999, Max: -999}
+		for _, temp := range temps {
+			stat.Min, stat.Max = min(stat.Min, int64(temp)), max(stat.Max, int64(temp))
+			stat.Sum += int64(temp)
+			stat.SumSquares += int64(temp) * int64(temp)
+			stat.Count++
+		}
+		if extended {
+			stat.Histogram = newHistogram(temps...)
+			stat.Below, stat.Above = int64(i), int64(2*i)
+		}
+		result.Stations = append(result.Stations, stat)
+	}
+	if extended {
+		result.Stats = Stats{Percentiles: true, Variance: true, Outliers: &TemperatureRange{Min: -100, Max: 100}}
+	}
+	return result
+}
+
+// TestEncoders compares the output of every encoder with the golden files in
+// testdata/encode, and checks the station names read back unchanged.
+func TestEncoders(t *testing.T) {
+	for _, extended := range []bool{false, true} {
+		result := encoderResult(extended)
+		for _, format := range EncoderFormats() {
+			name := format
+			if extended {
+				name += "_stats"
+			}
+			t.Run(name, func(t *testing.T) {
+				var got bytes.Buffer
+				if err := Encode(&got, format, result); err != nil {
+					t.Fatal(err)
+				}
+
+				path := filepath.Join("testdata", "encode", name+".golden")
+				if *update {
+					if err := os.WriteFile(path, got.Bytes(), 0666); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.String() != string(want) {
+					t.Errorf("got\n%s\nwant\n%s", got.String(), want)
+				}
+
+				if names := decodeStationNames(t, format, got.Bytes()); names != nil && !equalStrings(names, escapedNames) {
+					t.Errorf("read back stations %q, want %q", names, escapedNames)
+				}
+			})
+		}
+	}
+}
+
+// decodeStationNames parses the output of an encoder and returns the
+// station names, nil for formats that cannot be parsed back.
+func decodeStationNames(t *testing.T, format string, output []byte) []string {
+	t.Helper()
+	names := []string{}
+	switch format {
+	case "json":
+		var stations []struct{ Station string }
+		if err := json.Unmarshal(output, &stations); err != nil {
+			t.Fatal(err)
+		}
+		for _, stat := range stations {
+			names = append(names, stat.Station)
+		}
+	case "ndjson":
+		for _, line := range bytes.Split(bytes.TrimSuffix(output, []byte("\n")), []byte("\n")) {
+			var stat struct{ Station string }
+			if err := json.Unmarshal(line, &stat); err != nil {
+				t.Fatalf("line %q: %v", line, err)
+			}
+			names = append(names, stat.Station)
+		}
+	case "csv":
+		records, err := csv.NewReader(bytes.NewReader(output)).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, record := range records[1:] {
+			if len(record) != len(records[0]) {
+				t.Fatalf("record %q has %d fields, the header %d", record, len(record), len(records[0]))
+			}
+			names = append(names, record[0])
+		}
+	default:
+		return nil
+	}
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package brc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// StationFilter selects stations by exact name, name prefix or regular
+// expression, a station matching any of them. An empty filter matches every
+// station.
+type StationFilter struct {
+	Names    []string
+	Prefixes []string
+	Regexp   *regexp.Regexp
+}
+
+// IsEmpty reports whether the filter matches every station.
+func (f StationFilter) IsEmpty() bool {
+	return len(f.Names) == 0 && len(f.Prefixes) == 0 && f.Regexp == nil
+}
+
+// onlyNames reports whether the filter only selects exact names, which
+// solution5 and solution6 check before aggregating a line.
+func (f StationFilter) onlyNames() bool {
+	return len(f.Names) > 0 && len(f.Prefixes) == 0 && f.Regexp == nil
+}
+
+// Match reports whether station is selected by the filter.
+func (f StationFilter) Match(station string) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	for _, name := range f.Names {
+		if name == station {
+			return true
+		}
+	}
+	for _, prefix := range f.Prefixes {
+		if strings.HasPrefix(station, prefix) {
+			return true
+		}
+	}
+	return f.Regexp != nil && f.Regexp.MatchString(station)
+}
+
+// Filter returns the result keeping only the stations matched by filter.
+func (r Result) Filter(filter StationFilter) Result {
+	if filter.IsEmpty() {
+		return r
+	}
+	stations := make([]StationStats, 0, len(r.Stations))
+	for _, stat := range r.Stations {
+		if filter.Match(stat.Station) {
+			stations = append(stations, stat)
+		}
+	}
+	r.Stations = stations
+	return r
+}
+
+// newStationSet returns a table of the names of filter hashed with hash, nil
+// when the filter does not only select exact names.
+func newStationSet(filter StationFilter, hash func(station []byte) uint64) *stationTable[struct{}] {
+	if !filter.onlyNames() {
+		return nil
+	}
+	set := newStationTable[struct{}](16)
+	for _, name := range filter.Names {
+		if name != "" { // never in a valid line, and the table can't hold it
+			set.get(hash([]byte(name)), []byte(name))
+		}
+	}
+	return set
+}
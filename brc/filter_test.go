@@ -0,0 +1,78 @@
+package brc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// TestStationFilter compares the stations of solvers given Input.Stations,
+// which solution5 and solution6 apply while parsing when it only has names,
+// with Result.Filter applied to all the stations.
+func TestStationFilter(t *testing.T) {
+	// names shorter than, as long as and longer than the 8-byte words of
+	// solution6, some sharing a prefix and suffix
+	names := []string{"A", "Oslo", "Hamburg", "Helsinki", "Reykjavík", "Ouagadougou", "San Diego", "San José",
+		"Abcdefghijklmnop", "Abcdefghijklmnopq", "Station 00042 of the network", "Station 00043 of the network"}
+	r := rand.New(rand.NewSource(1))
+	var data []byte
+	for i := 0; i < 5000; i++ {
+		data = fmt.Appendf(data, "%s;%.1f\n", names[r.Intn(len(names))], float64(r.Intn(1999)-999)/10)
+	}
+	path := filepath.Join(t.TempDir(), "measurements.txt")
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	filters := []struct {
+		name     string
+		filter   StationFilter
+		stations int
+	}{
+		{"short names", StationFilter{Names: []string{"A", "Oslo", "Hamburg"}}, 3},
+		{"long names", StationFilter{Names: []string{"Helsinki", "Ouagadougou", "Abcdefghijklmnop", "Station 00042 of the network"}}, 4},
+		{"missing and empty names", StationFilter{Names: []string{"Reykjavík", "Bergen", "", "Station 00044 of the network"}}, 1},
+		{"prefixes", StationFilter{Prefixes: []string{"San ", "Abcdefghijklmnop"}}, 4},
+		{"regexp", StationFilter{Regexp: regexp.MustCompile(`^Station .* network$|k$`)}, 3},
+		{"names, prefixes and regexp", StationFilter{Names: []string{"Oslo"}, Prefixes: []string{"Ouaga"}, Regexp: regexp.MustCompile(`0042`)}, 3},
+	}
+	ctx := context.Background()
+	for _, solver := range Solvers() {
+		for _, in := range solverInputs {
+			t.Run(solver.Name()+"/"+in.name, func(t *testing.T) {
+				input := Input{FilePath: path, IO: in.io, Workers: in.workers, SegmentSize: in.segmentSize}
+				if in.reader {
+					input.FilePath, input.Reader = "", bytes.NewReader(data)
+				}
+				all, err := solver.Solve(ctx, input)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, tt := range filters {
+					want := all.Filter(tt.filter).Stations
+					if len(want) != tt.stations {
+						t.Fatalf("%s: Result.Filter kept %d stations, want %d", tt.name, len(want), tt.stations)
+					}
+					if in.reader {
+						input.Reader = bytes.NewReader(data)
+					}
+					input.Stations = tt.filter
+					got, err := solver.Solve(ctx, input)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !reflect.DeepEqual(got.Stations, want) {
+						t.Errorf("%s: got %v, want %v", tt.name, got.Stations, want)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package brc
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// validTemperature is the only temperature shape the parsers accept.
+var validTemperature = regexp.MustCompile(`^-?[0-9]{1,2}\.[0-9]$`)
+
+func FuzzTemperature(f *testing.F) {
+	for _, seed := range []string{"0.0", "-0.0", "9.9", "-99.9", "12.3", "-5.0", "1", "1.", ".5", "100.0", "1.23", "--1.0", "1;2", "", "-", "1.2\n"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		valid := validTemperature.MatchString(s)
+		if got := len(s) > 0 && temperatureLength([]byte(s)) == len(s); got != valid {
+			t.Fatalf("temperatureLength(%q) = %d, valid %v", s, temperatureLength([]byte(s)), valid)
+		}
+
+		var degrees float64
+		if valid {
+			var err error
+			if degrees, err = strconv.ParseFloat(s, 64); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		line := []byte("x;" + s + "\n")
+		s5 := func(processLines s5LineProcessor) func(rejects *lineRejecter) (float64, error) {
+			return func(rejects *lineRejecter) (float64, error) {
+				table := newStationTable[s5WeatherStationStats](4)
+				err := processLines(block{data: line, line: 1}, table, rejects, newS5Options(Input{}, fnvHash))
+				return float64(onlyStats(table).min) / 10, err
+			}
+		}
+		parsers := map[string]func(rejects *lineRejecter) (float64, error){
+			"processLinesS2": func(rejects *lineRejecter) (float64, error) {
+				table := newStationTable[weatherStationStats](4)
+				err := processLinesS2(block{data: line, line: 1}, table, rejects)
+				return onlyStats(table).min, err
+			},
+			"processLinesS5": s5(processLinesS5),
+			"processLinesS6": s5(processLinesS6),
+		}
+		for name, parse := range parsers {
+			got, err := parse(&lineRejecter{policy: PolicyStrict})
+
+			var malformed *MalformedLineError
+			switch {
+			case !valid && strings.Contains(s, "\n"):
+				// more than one line, the first one may be valid
+			case !valid && !errors.As(err, &malformed):
+				t.Fatalf("%s accepted %q: %v", name, s, err)
+			case valid && err != nil:
+				t.Fatalf("%s rejected %q: %v", name, s, err)
+			case valid && got != degrees:
+				t.Fatalf("%s parsed %q as %v, want %v", name, s, got, degrees)
+			}
+		}
+		if !valid {
+			return
+		}
+
+		if got := parseTemperature([]byte(s + "\n")); got != degrees {
+			t.Fatalf("parseTemperature(%q) = %v, want %v", s, got, degrees)
+		}
+		want := int32(math.Round(degrees * 10))
+		if got, n := parseTemperatureWord(loadWord([]byte(s + "\n"))); got != want || n != len(s)+1 {
+			t.Fatalf("parseTemperatureWord(%q) = %d, %d, want %d, %d", s, got, n, want, len(s)+1)
+		}
+	})
+}
+
+// onlyStats returns the stats of the only station of table.
+func onlyStats[V any](table *stationTable[V]) V {
+	var stats V
+	table.each(func(_ []byte, s *V) {
+		stats = *s
+	})
+	return stats
+}
+
+func FuzzStationTable(f *testing.F) {
+	f.Add([]byte("Hamburg\x00Oslo\x00Hamburg\x00Bulawayo"))
+	f.Add([]byte("a\x00b\x00c\x00d\x00e\x00f\x00g\x00a\x00ab\x00ba"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// a weak hash collides a lot and the tables grow from 4 slots
+		hash := func(name []byte) uint64 { return uint64(name[0] % 4) }
+		tables := []*stationTable[int]{newStationTable[int](4), newStationTable[int](4)}
+		counts := []map[string]int{{}, {}}
+		for i, name := range bytes.Split(data, []byte{0}) {
+			if len(name) == 0 {
+				continue
+			}
+			count, _ := tables[i%2].get(hash(name), name)
+			*count++
+			counts[i%2][string(name)]++
+		}
+
+		check := func(table *stationTable[int], want map[string]int) {
+			t.Helper()
+			got := map[string]int{}
+			table.each(func(station []byte, count *int) {
+				if _, ok := got[string(station)]; ok {
+					t.Fatalf("station %q is twice in the table", station)
+				}
+				got[string(station)] = *count
+			})
+			if len(got) != len(want) || table.size != len(want) {
+				t.Fatalf("got %d stations (size %d), want %d", len(got), table.size, len(want))
+			}
+			for station, count := range want {
+				if got[station] != count {
+					t.Fatalf("station %q: got %d, want %d", station, got[station], count)
+				}
+				if !table.contains(hash([]byte(station)), []byte(station)) {
+					t.Fatalf("station %q not found", station)
+				}
+			}
+		}
+		check(tables[0], counts[0])
+		check(tables[1], counts[1])
+
+		tables[0].merge(tables[1], func(dst, src *int) { *dst += *src })
+		for station, count := range counts[1] {
+			counts[0][station] += count
+		}
+		check(tables[0], counts[0])
+	})
+}
+
+func FuzzSplitFile(f *testing.F) {
+	f.Add([]byte("Hamburg;12.0\nOslo;-3.4\nBulawayo;8.9\n"), uint8(2))
+	f.Add([]byte("a;1.0\nb;2.0"), uint8(8))
+	f.Add([]byte("\n\n\n"), uint8(3))
+	f.Add([]byte(strings.Repeat("x", 300)+"\n"), uint8(4))
+	f.Add([]byte{}, uint8(1))
+	f.Fuzz(func(t *testing.T, data []byte, count uint8) {
+		n := int(count)%32 + 1
+		chunks, err := splitFile(bytes.NewReader(data), int64(len(data)), n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunks) > n {
+			t.Fatalf("got %d chunks, want at most %d", len(chunks), n)
+		}
+
+		var end int64
+		for i, chunk := range chunks {
+			if chunk.offset != end {
+				t.Fatalf("chunk %d starts at %d, the previous one ends at %d", i, chunk.offset, end)
+			}
+			if chunk.size <= 0 {
+				t.Fatalf("chunk %d is empty", i)
+			}
+			end = chunk.offset + chunk.size
+			if end > int64(len(data)) {
+				t.Fatalf("chunk %d ends at %d past the %d bytes of data", i, end, len(data))
+			}
+			if end < int64(len(data)) && data[end-1] != '\n' {
+				t.Fatalf("chunk %d ends at %d in the middle of a line", i, end)
+			}
+		}
+		if end != int64(len(data)) {
+			t.Fatalf("chunks cover %d of %d bytes", end, len(data))
+		}
+	})
+}
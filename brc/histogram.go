@@ -0,0 +1,102 @@
+package brc
+
+import "math"
+
+// Bounds of the temperatures accepted by the parsers, in tenths of a degree.
+const (
+	minTenths = -999
+	maxTenths = 999
+)
+
+// Histogram counts the measurements of a station per tenth of a degree,
+// from -99.9 to 99.9, which gives exact percentiles.
+type Histogram [maxTenths - minTenths + 1]uint32
+
+func (h *Histogram) add(tenths int32) {
+	h[tenths-minTenths]++
+}
+
+func (h *Histogram) merge(other *Histogram) {
+	for i, n := range other {
+		h[i] += n
+	}
+}
+
+// Count returns the number of measurements of the given temperature in tenths.
+func (h *Histogram) Count(tenths int64) int64 {
+	if tenths < minTenths || tenths > maxTenths {
+		return 0
+	}
+	return int64(h[tenths-minTenths])
+}
+
+// Percentile returns the p-th percentile (0 < p <= 100) in tenths of a
+// degree using the nearest-rank method: the lowest temperature with at
+// least p% of the measurements at or below it. The median is Percentile(50).
+func (h *Histogram) Percentile(p float64) int64 {
+	var total int64
+	for _, n := range h {
+		total += int64(n)
+	}
+	rank := max(int64(math.Ceil(p/100*float64(total))), 1)
+
+	var seen int64
+	for i, n := range h {
+		seen += int64(n)
+		if seen >= rank {
+			return int64(i + minTenths)
+		}
+	}
+	return maxTenths
+}
+
+// Mode returns the most frequent temperature in tenths of a degree, the
+// lowest one on ties.
+func (h *Histogram) Mode() int64 {
+	mode := 0
+	for i, n := range h {
+		if n > h[mode] {
+			mode = i
+		}
+	}
+	return int64(mode + minTenths)
+}
+
+// Bucket counts the measurements from From (inclusive) to To (exclusive) in
+// tenths of a degree.
+type Bucket struct {
+	From, To int64
+	Count    int64
+}
+
+// Buckets groups the histogram in buckets of width tenths of a degree
+// aligned on multiples of width, from the lowest to the highest measurement,
+// keeping the empty buckets in between.
+func (h *Histogram) Buckets(width int64) []Bucket {
+	var buckets []Bucket
+	for i, n := range h {
+		if n == 0 {
+			continue
+		}
+		tenths := int64(i + minTenths)
+		from := floorDiv(tenths, width) * width
+		for len(buckets) == 0 || buckets[len(buckets)-1].From < from {
+			next := from
+			if len(buckets) > 0 {
+				next = buckets[len(buckets)-1].To
+			}
+			buckets = append(buckets, Bucket{From: next, To: next + width})
+		}
+		buckets[len(buckets)-1].Count += int64(n)
+	}
+	return buckets
+}
+
+// floorDiv returns num/den rounded toward negative infinity for den > 0.
+func floorDiv(num, den int64) int64 {
+	q := num / den
+	if num%den != 0 && num < 0 {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,128 @@
+package brc
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// histogramEncoderFunc writes the histograms of a Result, whose stations
+// must have one, in buckets of width tenths of a degree.
+type histogramEncoderFunc func(output io.Writer, result Result, width int64) error
+
+var histogramEncoders = map[string]histogramEncoderFunc{
+	"bars": encodeHistogramBars,
+	"csv":  encodeHistogramCSV,
+	"json": encodeHistogramJSON,
+}
+
+// HistogramFormats returns the sorted names of the histogram formats.
+func HistogramFormats() []string {
+	formats := make([]string, 0, len(histogramEncoders))
+	for format := range histogramEncoders {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// EncodeHistograms writes the histograms of result to output in the given
+// format, in buckets of width tenths of a degree.
+func EncodeHistograms(output io.Writer, format string, result Result, width int64) error {
+	encode, ok := histogramEncoders[format]
+	if !ok {
+		return fmt.Errorf("invalid histogram format %q, should be one of %v", format, HistogramFormats())
+	}
+	if width < 1 {
+		return fmt.Errorf("invalid bucket width %d, should be positive", width)
+	}
+	for _, stat := range result.Stations {
+		if stat.Histogram == nil {
+			return fmt.Errorf("station %q has no histogram, compute it with Stats.Percentiles", stat.Station)
+		}
+	}
+	return encode(output, result, width)
+}
+
+// encodeHistogramCSV writes one record per station and bucket.
+func encodeHistogramCSV(output io.Writer, result Result, width int64) error {
+	writer := csv.NewWriter(output)
+	if err := writer.Write([]string{"station", "from", "to", "count"}); err != nil {
+		return err
+	}
+	for _, stat := range result.Stations {
+		for _, bucket := range stat.Histogram.Buckets(width) {
+			record := []string{
+				stat.Station,
+				string(appendTenths(nil, bucket.From)),
+				string(appendTenths(nil, bucket.To)),
+				strconv.FormatInt(bucket.Count, 10),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+type jsonBucket struct {
+	From  tenths `json:"from"`
+	To    tenths `json:"to"`
+	Count int64  `json:"count"`
+}
+
+type jsonHistogram struct {
+	Station string       `json:"station"`
+	Buckets []jsonBucket `json:"buckets"`
+}
+
+// encodeHistogramJSON writes a JSON array with the buckets of each station.
+func encodeHistogramJSON(output io.Writer, result Result, width int64) error {
+	histograms := make([]jsonHistogram, 0, len(result.Stations))
+	for _, stat := range result.Stations {
+		buckets := stat.Histogram.Buckets(width)
+		histogram := jsonHistogram{Station: stat.Station, Buckets: make([]jsonBucket, 0, len(buckets))}
+		for _, bucket := range buckets {
+			histogram.Buckets = append(histogram.Buckets, jsonBucket{tenths(bucket.From), tenths(bucket.To), bucket.Count})
+		}
+		histograms = append(histograms, histogram)
+	}
+
+	encoder := json.NewEncoder(output)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(histograms)
+}
+
+// barWidth is the number of characters of the longest bar of a station.
+const barWidth = 50
+
+// encodeHistogramBars draws a horizontal bar chart per station for a
+// terminal, the bars scaled to the largest bucket of the station.
+func encodeHistogramBars(output io.Writer, result Result, width int64) error {
+	writer := bufio.NewWriter(output)
+	for i, stat := range result.Stations {
+		if i > 0 {
+			writer.WriteString("\n")
+		}
+		fmt.Fprintf(writer, "%s (%d measurements)\n", stat.Station, stat.Count)
+
+		buckets := stat.Histogram.Buckets(width)
+		var largest int64
+		for _, bucket := range buckets {
+			largest = max(largest, bucket.Count)
+		}
+		for _, bucket := range buckets {
+			length := int((bucket.Count*barWidth + largest - 1) / largest) // non-empty buckets get a bar
+			fmt.Fprintf(writer, "%6s %6s |%-*s %d\n",
+				appendTenths(nil, bucket.From), appendTenths(nil, bucket.To), barWidth, strings.Repeat("#", length), bucket.Count)
+		}
+	}
+	return writer.Flush()
+}
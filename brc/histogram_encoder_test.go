@@ -0,0 +1,113 @@
+package brc
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuckets(t *testing.T) {
+	tests := []struct {
+		name  string
+		temps []int32
+		width int64
+		want  []Bucket
+	}{
+		{"width 1", []int32{-1, 0, 2}, 1, []Bucket{{-1, 0, 1}, {0, 1, 1}, {1, 2, 0}, {2, 3, 1}}},
+		{"negative alignment", []int32{-15, -10, -1, 0, 9, 10}, 10, []Bucket{{-20, -10, 1}, {-10, 0, 2}, {0, 10, 2}, {10, 20, 1}}},
+		{"empty buckets in between", []int32{-25, 15}, 10, []Bucket{{-30, -20, 1}, {-20, -10, 0}, {-10, 0, 0}, {0, 10, 0}, {10, 20, 1}}},
+		{"single value", []int32{-999}, 7, []Bucket{{-1001, -994, 1}}},
+		{"lowest and highest", []int32{-999, 999}, 1000, []Bucket{{-1000, 0, 1}, {0, 1000, 1}}},
+		{"wider than the range", []int32{-5, 5, 5}, 5000, []Bucket{{-5000, 0, 1}, {0, 5000, 2}}},
+	}
+	for _, tt := range tests {
+		if got := newHistogram(tt.temps...).Buckets(tt.width); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Buckets(%d) of %v = %v, want %v", tt.name, tt.width, tt.temps, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeHistograms(t *testing.T) {
+	result := Result{Stations: []StationStats{
+		{Station: `Hamburg "Nord", DE`, Count: 3, Histogram: newHistogram(-15, -10, 4)},
+		{Station: "Oslo", Count: 1, Histogram: newHistogram(0)},
+	}}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"csv", `station,from,to,count
+"Hamburg ""Nord"", DE",-2.0,-1.0,1
+"Hamburg ""Nord"", DE",-1.0,0.0,1
+"Hamburg ""Nord"", DE",0.0,1.0,1
+Oslo,0.0,1.0,1
+`},
+		{"json", `[{"station":"Hamburg \"Nord\", DE","buckets":[{"from":-2.0,"to":-1.0,"count":1},{"from":-1.0,"to":0.0,"count":1},{"from":0.0,"to":1.0,"count":1}]},{"station":"Oslo","buckets":[{"from":0.0,"to":1.0,"count":1}]}]
+`},
+		{"bars", `Hamburg "Nord", DE (3 measurements)
+  -2.0   -1.0 |################################################## 1
+  -1.0    0.0 |################################################## 1
+   0.0    1.0 |################################################## 1
+
+Oslo (1 measurements)
+   0.0    1.0 |################################################## 1
+`},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := EncodeHistograms(&out, tt.format, result, 10); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.format, out.String(), tt.want)
+		}
+	}
+}
+
+func TestEncodeHistogramBarsScale(t *testing.T) {
+	// bars are scaled to the largest bucket, non-empty ones get at least one #
+	temps := []int32{5}
+	for i := 0; i < 200; i++ {
+		temps = append(temps, 15)
+	}
+	result := Result{Stations: []StationStats{{Station: "Oslo", Count: int64(len(temps)), Histogram: newHistogram(temps...)}}}
+	var out bytes.Buffer
+	if err := EncodeHistograms(&out, "bars", result, 10); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %q, want a title and 2 bars", out.String())
+	}
+	for i, want := range []int{1, barWidth} {
+		if got := strings.Count(lines[i+1], "#"); got != want {
+			t.Errorf("bar %q has %d #, want %d", lines[i+1], got, want)
+		}
+	}
+}
+
+func TestEncodeHistogramsErrors(t *testing.T) {
+	withHistogram := Result{Stations: []StationStats{{Station: "Oslo", Count: 1, Histogram: newHistogram(0)}}}
+	withoutHistogram := Result{Stations: []StationStats{{Station: "Oslo", Count: 1}}}
+	tests := []struct {
+		name   string
+		format string
+		result Result
+		width  int64
+	}{
+		{"invalid format", "xml", withHistogram, 10},
+		{"zero width", "csv", withHistogram, 0},
+		{"negative width", "csv", withHistogram, -10},
+		{"no histogram", "bars", withoutHistogram, 10},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := EncodeHistograms(&out, tt.format, tt.result, tt.width); err == nil {
+			t.Errorf("%s: got no error", tt.name)
+		}
+		if out.Len() != 0 {
+			t.Errorf("%s: wrote %q before failing", tt.name, out.String())
+		}
+	}
+}
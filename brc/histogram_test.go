@@ -0,0 +1,68 @@
+package brc
+
+import "testing"
+
+// newHistogram returns the histogram of the given temperatures in tenths.
+func newHistogram(temps ...int32) *Histogram {
+	h := new(Histogram)
+	for _, temp := range temps {
+		h.add(temp)
+	}
+	return h
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name  string
+		temps []int32
+		p     float64
+		want  int64
+	}{
+		{"single value median", []int32{-5}, 50, -5},
+		{"single value lowest", []int32{-5}, 0.001, -5},
+		{"single value highest", []int32{-5}, 100, -5},
+		// nearest rank: the ceil(p% of 4)-th measurement
+		{"rank 1", []int32{-10, -10, 0, 10}, 1, -10},
+		{"median on a rank", []int32{-10, -10, 0, 10}, 50, -10},
+		{"median past a rank", []int32{-10, -10, 0, 10}, 50.1, 0},
+		{"p75", []int32{-10, -10, 0, 10}, 75, 0},
+		{"p100", []int32{-10, -10, 0, 10}, 100, 10},
+		{"p90 of 10", []int32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 90, 9},
+		{"p99 of 10", []int32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 99, 10},
+		{"negative bound", []int32{-999, -999, 999}, 50, -999},
+		{"positive bound", []int32{-999, 999, 999}, 50, 999},
+	}
+	for _, tt := range tests {
+		if got := newHistogram(tt.temps...).Percentile(tt.p); got != tt.want {
+			t.Errorf("%s: Percentile(%v) of %v = %d, want %d", tt.name, tt.p, tt.temps, got, tt.want)
+		}
+	}
+}
+
+func TestMode(t *testing.T) {
+	tests := []struct {
+		name  string
+		temps []int32
+		want  int64
+	}{
+		{"single value", []int32{-5}, -5},
+		{"most frequent", []int32{-3, 7, 7, 1}, 7},
+		{"lowest on ties", []int32{7, 7, -3, -3, 1}, -3},
+		{"bounds", []int32{999, -999}, -999},
+	}
+	for _, tt := range tests {
+		if got := newHistogram(tt.temps...).Mode(); got != tt.want {
+			t.Errorf("%s: Mode() of %v = %d, want %d", tt.name, tt.temps, got, tt.want)
+		}
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	h := newHistogram(-999, 0, 0)
+	h.merge(newHistogram(0, 999))
+	for temp, want := range map[int64]int64{-999: 1, 0: 3, 999: 1, 1: 0, -1000: 0, 1000: 0} {
+		if got := h.Count(temp); got != want {
+			t.Errorf("Count(%d) = %d, want %d", temp, got, want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package brc
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile maps the whole file read-only into memory and returns the mapping
+// together with a function to unmap it.
+func mmapFile(filePath string) ([]byte, func() error, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close() // the mapping stays valid after the file is closed
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if stat.Size() == 0 {
+		return []byte{}, func() error { return nil }, nil // mmap rejects zero length mappings
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(stat.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// every worker reads its chunk front to back, ask for aggressive read-ahead
+	if err := syscall.Madvise(data, syscall.MADV_SEQUENTIAL); err != nil {
+		syscall.Munmap(data)
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
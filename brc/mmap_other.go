@@ -0,0 +1,9 @@
+//go:build !linux
+
+package brc
+
+import "errors"
+
+func mmapFile(filePath string) ([]byte, func() error, error) {
+	return nil, nil, errors.New("mmap input is not supported on this platform")
+}
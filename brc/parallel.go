@@ -0,0 +1,143 @@
+package brc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultSegmentSize = 8 * 1024 * 1024
+
+type workerResult[T any] struct {
+	stats  T
+	worker WorkerStats
+	err    error
+}
+
+// startWorkers runs process in a pool of input.Workers goroutines that pull
+// line-aligned segments of the input file from a shared queue or, when
+// reading from input.Reader, blocks of the stream, so a slow worker only
+// delays the segment it is on. The results are sent to the returned
+// channel, n being their number. stop cancels the remaining workers and
+// releases the input once they are done, it must be called even when
+// returning early on error.
+func startWorkers[T any](ctx context.Context, input Input, process func(blocks blockSource) (T, error)) (results <-chan workerResult[T], n int, stop func(), err error) {
+	workers := input.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	segmentSize := input.SegmentSize
+	if segmentSize <= 0 {
+		segmentSize = defaultSegmentSize
+	}
+
+	input, closeInput, err := decompressInput(input, workers)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	mapped, unmap, err := mapInput(input)
+	if err != nil {
+		closeInput()
+		return nil, 0, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var stream *blockStream
+	var source blockSource
+	if input.Reader != nil {
+		stream = newBlockStream(ctx, input.Reader, workers)
+		source = stream.forEach
+	} else {
+		source, err = segmentSource(ctx, input.FilePath, mapped, segmentSize)
+		if err != nil {
+			cancel()
+			unmap()
+			closeInput()
+			return nil, 0, nil, err
+		}
+	}
+
+	var wg sync.WaitGroup
+	resultsChan := make(chan workerResult[T], workers) // never blocks a worker
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var worker WorkerStats
+			start := time.Now()
+			stats, err := process(func(fn func(b block) error) error {
+				return source(func(b block) error {
+					worker.Bytes += int64(len(b.data))
+					return fn(b)
+				})
+			})
+			worker.Busy = time.Since(start)
+			resultsChan <- workerResult[T]{stats, worker, locateMalformedLine(input, err)}
+		}()
+	}
+
+	stop = func() {
+		cancel()
+		wg.Wait() // workers may still read the mapping
+		if stream != nil {
+			<-stream.done // the stream may still read from the decoder
+		}
+		unmap()
+		closeInput()
+	}
+	return resultsChan, workers, stop, nil
+}
+
+// segmentSource splits the file, or its mapping when mapped is not nil,
+// into segments of about segmentSize bytes and returns a blockSource that
+// all workers share, each call taking the next segment from an atomic
+// cursor until none is left.
+func segmentSource(ctx context.Context, filePath string, mapped []byte, segmentSize int64) (blockSource, error) {
+	var reader io.ReaderAt = bytes.NewReader(mapped)
+	size := int64(len(mapped))
+	if mapped == nil {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		stat, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		reader, size = file, stat.Size()
+	}
+
+	segments, err := splitFile(reader, size, int(max((size+segmentSize-1)/segmentSize, 1)))
+	if err != nil {
+		return nil, err
+	}
+
+	var next atomic.Int64
+	return func(fn func(b block) error) error {
+		for {
+			i := next.Add(1) - 1
+			if i >= int64(len(segments)) {
+				return nil
+			}
+			segment := segments[i]
+			err := fileChunkSource(ctx, filePath, segment, mapped)(fn)
+			var malformed *MalformedLineError
+			if errors.As(err, &malformed) {
+				return err // tells where it is already, its line is filled in later
+			}
+			if err != nil {
+				return fmt.Errorf("segment at offset %d: %w", segment.offset, err)
+			}
+		}
+	}, nil
+}
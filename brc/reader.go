@@ -0,0 +1,290 @@
+package brc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+const blockSize = 1024 * 1024 // size of the blocks the parallel solutions parse at once
+
+// contextReader fails with the context's error once ctx is done, so a
+// worker stops at its next read after another worker failed.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r readCloser) Close() error {
+	return r.close()
+}
+
+// openInput returns a reader for the decompressed input.Reader or
+// input.FilePath.
+func openInput(input Input) (io.ReadCloser, error) {
+	input, closeInput, err := decompressInput(input, 1)
+	if err != nil {
+		return nil, err
+	}
+	if input.Reader != nil {
+		return readCloser{input.Reader, closeInput}, nil
+	}
+	return os.Open(input.FilePath)
+}
+
+// openChunk opens filePath and returns a reader for size bytes starting at offset.
+// The caller is responsible for closing the returned file.
+func openChunk(ctx context.Context, filePath string, offset, size int64) (*os.File, io.Reader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, contextReader{ctx, &io.LimitedReader{R: file, N: size}}, nil
+}
+
+// block is a piece of the input made of newline terminated lines.
+type block struct {
+	data   []byte
+	offset int64 // of data in the input
+	line   int64 // number of the first line in data, 0 if unknown
+}
+
+// lineAt returns the number of the line starting at data[pos], 0 if unknown.
+func (b block) lineAt(pos int) int64 {
+	if b.line == 0 {
+		return 0
+	}
+	return b.line + int64(bytes.Count(b.data[:pos], []byte{'\n'}))
+}
+
+// blockSource calls fn with consecutive blocks of the input until it is
+// exhausted or fn returns an error.
+type blockSource func(fn func(b block) error) error
+
+// fileChunkSource returns the blocks of a file chunk, parsed in place from
+// mapped when the file is memory-mapped and read through a buffer otherwise.
+func fileChunkSource(ctx context.Context, filePath string, chunk fileChunk, mapped []byte) blockSource {
+	if mapped != nil {
+		return func(fn func(b block) error) error {
+			return forEachMappedBlock(ctx, mapped[chunk.offset:chunk.offset+chunk.size], chunk.offset, fn)
+		}
+	}
+	return func(fn func(b block) error) error {
+		return forEachReadBlock(ctx, filePath, chunk.offset, chunk.size, fn)
+	}
+}
+
+// forEachReadBlock reads size bytes of filePath starting at offset and calls
+// fn with each block of newline terminated lines.
+func forEachReadBlock(ctx context.Context, filePath string, offset, size int64, fn func(b block) error) error {
+	file, reader, err := openChunk(ctx, filePath, offset, size)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return forEachBlock(reader, offset, 0, fn)
+}
+
+// forEachBlock reads reader through a 1MB buffer and calls fn with each
+// block of newline terminated lines, offset and line being the position of
+// the reader in the input (line 0 if unknown). A line longer than the
+// buffer grows it, so the parser rejects the whole line.
+func forEachBlock(reader io.Reader, offset, line int64, fn func(b block) error) error {
+	buf := make([]byte, blockSize)
+	start := 0
+
+	for {
+		if start == len(buf) {
+			buf = append(buf, make([]byte, len(buf))...)
+		}
+		nb, err := reader.Read(buf[start:])
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if start+nb == 0 {
+			break
+		}
+		chunk := buf[:start+nb]
+		if nb == 0 && err == io.EOF {
+			chunk = append(chunk, '\n') // terminate the last line, buf has room for it
+		}
+
+		nl := bytes.LastIndexByte(chunk, '\n')
+		if nl < 0 {
+			start = len(chunk) // read the rest of the line
+			continue
+		}
+
+		b := block{data: chunk[:nl+1], offset: offset, line: line}
+		if err := fn(b); err != nil {
+			return err
+		}
+		offset += int64(len(b.data))
+		if line != 0 {
+			line += int64(bytes.Count(b.data, []byte{'\n'}))
+		}
+		start = copy(buf, chunk[nl+1:])
+	}
+	return nil
+}
+
+// forEachMappedBlock calls fn with consecutive blocks of about 1MB of newline
+// terminated lines of data, in place and without copying. A line longer
+// than that makes a block of its own. Like forEachReadBlock it checks ctx
+// between blocks.
+func forEachMappedBlock(ctx context.Context, data []byte, offset int64, fn func(b block) error) error {
+	for len(data) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		nl := bytes.LastIndexByte(data[:min(len(data), blockSize)], '\n')
+		if nl < 0 {
+			nl = bytes.IndexByte(data, '\n')
+		}
+		if nl < 0 {
+			// the last line has no newline, terminate a copy of it as data is read-only
+			last := append(append(make([]byte, 0, len(data)+1), data...), '\n')
+			return fn(block{data: last, offset: offset})
+		}
+
+		if err := fn(block{data: data[:nl+1], offset: offset}); err != nil {
+			return err
+		}
+		offset += int64(nl + 1)
+		data = data[nl+1:]
+	}
+	return nil
+}
+
+// blockStream splits a reader that cannot be seeked, like stdin or a
+// network stream, into blocks of newline terminated lines that are shared
+// by several workers through its forEach blockSource.
+type blockStream struct {
+	ctx    context.Context
+	blocks chan block  // filled blocks waiting for a worker
+	free   chan []byte // buffers that can be filled again
+	done   chan struct{}
+	err    error // read error, only valid once blocks is closed
+}
+
+func newBlockStream(ctx context.Context, r io.Reader, workers int) *blockStream {
+	s := &blockStream{
+		ctx:    ctx,
+		blocks: make(chan block, workers),
+		free:   make(chan []byte, 2*workers),
+		done:   make(chan struct{}),
+	}
+	for i := 0; i < cap(s.free); i++ {
+		s.free <- make([]byte, blockSize)
+	}
+	go s.read(r)
+	return s
+}
+
+func (s *blockStream) read(r io.Reader) {
+	defer close(s.done)
+	defer close(s.blocks)
+
+	var left []byte // incomplete last line of the previous block
+	offset, line := int64(0), int64(1)
+	for {
+		var buf []byte
+		select {
+		case buf = <-s.free:
+		case <-s.ctx.Done():
+			s.err = s.ctx.Err()
+			return
+		}
+
+		if len(left) >= len(buf) {
+			buf = make([]byte, 2*len(left)) // a line longer than the buffer, the parser rejects it
+		}
+		start := copy(buf, left)
+		nb, err := io.ReadFull(r, buf[start:])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			s.err = err
+			return
+		}
+		chunk := buf[:start+nb]
+		eof := err != nil
+
+		nl := bytes.LastIndexByte(chunk, '\n')
+		if eof && nl < len(chunk)-1 {
+			// terminate the last line, buf has room as ReadFull did not fill it
+			chunk = append(chunk, '\n')
+			nl = len(chunk) - 1
+		}
+		left = append(left[:0], chunk[nl+1:]...)
+
+		if nl < 0 {
+			s.free <- buf
+			if eof {
+				return // nothing left to parse
+			}
+			continue // read the rest of the line in a larger buffer
+		}
+		b := block{data: chunk[:nl+1], offset: offset, line: line}
+		offset += int64(len(b.data))
+		line += int64(bytes.Count(b.data, []byte{'\n'}))
+		select {
+		case s.blocks <- b:
+		case <-s.ctx.Done():
+			s.err = s.ctx.Err()
+			return
+		}
+		if eof {
+			return
+		}
+	}
+}
+
+// forEach is the blockSource shared by all workers reading the stream, it
+// hands out blocks until the stream is exhausted.
+func (s *blockStream) forEach(fn func(b block) error) error {
+	for b := range s.blocks {
+		err := fn(b)
+		s.free <- b.data[:cap(b.data)]
+		if err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+// mapInput maps the input file into memory when input.IO is IOMmap and
+// returns a nil slice for the buffered mode.
+func mapInput(input Input) ([]byte, func() error, error) {
+	switch input.IO {
+	case "", IOBuffered:
+		return nil, func() error { return nil }, nil
+	case IOMmap:
+		if input.Reader != nil {
+			return nil, nil, fmt.Errorf("io mode %s needs a file, not a reader", IOMmap)
+		}
+		return mmapFile(input.FilePath)
+	default:
+		return nil, nil, fmt.Errorf("invalid io mode %q, should be %s or %s", input.IO, IOBuffered, IOMmap)
+	}
+}
@@ -0,0 +1,183 @@
+package brc
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// StationStats holds the aggregated measurements of a single weather station.
+// Temperatures are stored in tenths of a degree so results of different
+// solutions can be compared exactly.
+type StationStats struct {
+	Station  string
+	Min, Max int64
+	Sum      int64
+	Count    int64
+	// SumSquares is the sum of the squared temperatures in hundredths of a
+	// degree squared, with Stats.Variance.
+	SumSquares int64
+	// Below and Above count the measurements outside of Stats.Outliers.
+	Below, Above int64
+	// Histogram holds the measurements of the station with
+	// Stats.Percentiles, nil otherwise.
+	Histogram *Histogram
+}
+
+// Mean returns the mean temperature in degrees.
+func (s StationStats) Mean() float64 {
+	return float64(s.Sum) / float64(s.Count) / 10
+}
+
+// MeanTenths returns the mean temperature in tenths of a degree, rounded
+// half up toward positive infinity as required by the challenge.
+func (s StationStats) MeanTenths() int64 {
+	return roundDiv(s.Sum, s.Count)
+}
+
+// Variance returns the population variance of the temperatures in degrees
+// squared, (n*SumSquares - Sum²) / n², with SumSquares set.
+func (s StationStats) Variance() float64 {
+	// n*SumSquares overflows int64 past a few billion measurements
+	count, sum := big.NewInt(s.Count), big.NewInt(s.Sum)
+	num := new(big.Int).Mul(count, big.NewInt(s.SumSquares))
+	num.Sub(num, sum.Mul(sum, sum))
+	den := count.Mul(count, count)
+	variance, _ := new(big.Rat).SetFrac(num, den.Mul(den, big.NewInt(100))).Float64()
+	return variance
+}
+
+// Stddev returns the population standard deviation of the temperatures in
+// degrees, with SumSquares set.
+func (s StationStats) Stddev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// String returns the stats formatted as "min/mean/max", followed by
+// "/median/p90/p99/mode" when there is a histogram.
+func (s StationStats) String() string {
+	buf := make([]byte, 0, 48)
+	buf = appendTenths(buf, s.Min)
+	buf = append(buf, '/')
+	buf = appendTenths(buf, s.MeanTenths())
+	buf = append(buf, '/')
+	buf = appendTenths(buf, s.Max)
+	if s.Histogram != nil {
+		for _, value := range s.percentiles() {
+			buf = append(buf, '/')
+			buf = appendTenths(buf, value)
+		}
+	}
+	return string(buf)
+}
+
+// percentiles returns the median, p90, p99 and mode of the histogram.
+func (s StationStats) percentiles() [4]int64 {
+	return [4]int64{
+		s.Histogram.Percentile(50),
+		s.Histogram.Percentile(90),
+		s.Histogram.Percentile(99),
+		s.Histogram.Mode(),
+	}
+}
+
+// Result is the outcome of a solver run, one entry per station sorted by name.
+type Result struct {
+	Stations []StationStats
+	Rejected int64         // malformed lines dropped by PolicySkip or PolicyReport
+	Workers  []WorkerStats // one per worker of the parallel solutions
+	Stats    Stats         // the extra statistics computed
+}
+
+// WorkerStats tells how much of the input a worker of a parallel solution
+// parsed and for how long, to spot the slowest one.
+type WorkerStats struct {
+	Bytes int64
+	Busy  time.Duration
+}
+
+// Write writes the result in the challenge format "{station=min/mean/max, ...}",
+// with "/variance/stddev" rounded to 2 decimals and "/below/above" outlier
+// counts appended when computed.
+func (r Result) Write(output io.Writer) error {
+	if _, err := fmt.Fprint(output, "{"); err != nil {
+		return err
+	}
+	for i, stat := range r.Stations {
+		if i > 0 {
+			if _, err := fmt.Fprint(output, ", "); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(output, "%s=%s", stat.Station, stat); err != nil {
+			return err
+		}
+		if r.Stats.Variance {
+			if _, err := fmt.Fprintf(output, "/%.2f/%.2f", stat.Variance(), stat.Stddev()); err != nil {
+				return err
+			}
+		}
+		if r.Stats.Outliers != nil {
+			if _, err := fmt.Fprintf(output, "/%d/%d", stat.Below, stat.Above); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintln(output, "}")
+	return err
+}
+
+// roundDiv returns num/den for den > 0 rounded half up toward positive
+// infinity, matching Java's Math.round used by the challenge's reference
+// implementation, e.g. 2.5 rounds to 3 and -2.5 rounds to -2.
+func roundDiv(num, den int64) int64 {
+	// floor((num + den/2) / den), kept in integers to stay exact
+	n, d := 2*num+den, 2*den
+	q := n / d
+	if n%d != 0 && n < 0 {
+		q-- // Go truncates toward zero, round toward negative infinity instead
+	}
+	return q
+}
+
+// appendTenths appends a value given in tenths with exactly one fractional
+// digit. Zero is never printed with a sign, unlike %.1f which prints -0.0.
+func appendTenths(buf []byte, tenths int64) []byte {
+	if tenths < 0 {
+		buf = append(buf, '-')
+		tenths = -tenths
+	}
+	buf = strconv.AppendInt(buf, tenths/10, 10)
+	return append(buf, '.', byte('0'+tenths%10))
+}
+
+// toTenths converts a temperature in degrees to tenths of a degree.
+func toTenths(temp float64) int64 {
+	return int64(math.Round(temp * 10))
+}
+
+// newResult builds a sorted Result from the float based stats used by solution1 to solution4.
+func newResult(weatherData map[string]*weatherStationStats, rejected int64) Result {
+	stations := make([]StationStats, 0, len(weatherData))
+	for station, stat := range weatherData {
+		stations = append(stations, StationStats{
+			Station: station,
+			Min:     toTenths(stat.min),
+			Max:     toTenths(stat.max),
+			Sum:     toTenths(stat.sum),
+			Count:   int64(stat.count),
+		})
+	}
+	sortStations(stations)
+	return Result{Stations: stations, Rejected: rejected}
+}
+
+func sortStations(stations []StationStats) {
+	sort.Slice(stations, func(i, j int) bool {
+		return stations[i].Station < stations[j].Station
+	})
+}
@@ -0,0 +1,127 @@
+package brc
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestMeanRounding(t *testing.T) {
+	// sum and count in tenths, the mean rounded half up toward positive
+	// infinity like Java's Math.round
+	tests := []struct {
+		sum, count int64
+		want       string
+	}{
+		{0, 1, "0.0"},
+		{25, 2, "1.3"},   // 1.25
+		{27, 2, "1.4"},   // 1.35
+		{-25, 2, "-1.2"}, // -1.25
+		{-27, 2, "-1.3"}, // -1.35
+		{-5, 2, "-0.2"},  // -0.25
+		{-3, 2, "-0.1"},  // -0.15
+		{-1, 2, "0.0"},   // -0.05, never -0.0
+		{-2, 5, "0.0"},   // -0.04
+		{-3, 5, "-0.1"},  // -0.06
+		{1, 2, "0.1"},    // 0.05
+		{10, 3, "0.3"},   // 0.333...
+		{20, 3, "0.7"},   // 0.666...
+		{-20, 3, "-0.7"}, // -0.666...
+		{-10, 3, "-0.3"}, // -0.333...
+		{3 * 999, 3, "99.9"},
+		{-3 * 999, 3, "-99.9"},
+		{999 + 998, 2, "99.9"},     // 99.85
+		{-999 - 998, 2, "-99.8"},   // -99.85
+		{25e8, 1e9, "0.3"},         // 0.25 over a billion measurements
+		{-25e8, 1e9, "-0.2"},       // -0.25 over a billion measurements
+		{999e9 - 5e8, 1e9, "99.9"}, // 99.85
+	}
+	for _, tt := range tests {
+		stat := StationStats{Sum: tt.sum, Count: tt.count}
+		if got := string(appendTenths(nil, stat.MeanTenths())); got != tt.want {
+			t.Errorf("mean of sum %d and count %d = %s, want %s", tt.sum, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestAppendTenths(t *testing.T) {
+	tests := []struct {
+		tenths int64
+		want   string
+	}{
+		{0, "0.0"},
+		{5, "0.5"},
+		{-5, "-0.5"},
+		{10, "1.0"},
+		{-10, "-1.0"},
+		{999, "99.9"},
+		{-999, "-99.9"},
+		{12345, "1234.5"},
+	}
+	for _, tt := range tests {
+		if got := string(appendTenths(nil, tt.tenths)); got != tt.want {
+			t.Errorf("appendTenths(%d) = %s, want %s", tt.tenths, got, tt.want)
+		}
+	}
+}
+
+func TestStationStatsString(t *testing.T) {
+	stat := StationStats{Station: "Hamburg", Min: -999, Max: 999, Sum: -5, Count: 2}
+	if got, want := stat.String(), "-99.9/-0.2/99.9"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
+
+// TestMergedVariance checks that the variance of stats aggregated in chunks
+// and combined with mergeS5 is the one computed in two passes over all the
+// measurements.
+func TestMergedVariance(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	options := newS5Options(Input{}, fnvHash)
+	station := []byte("Hamburg")
+	for _, count := range []int{1, 2, 7, 1000, 100_000} {
+		temps := make([]int32, count)
+		for i := range temps {
+			temps[i] = int32(r.Intn(maxTenths-minTenths+1) + minTenths)
+		}
+
+		var merged *s5WeatherStationStats
+		for rest := temps; len(rest) > 0; {
+			n := min(r.Intn(len(temps))+1, len(rest))
+			table := newStationTable[s5WeatherStationStats](4)
+			for _, temp := range rest[:n] {
+				aggregateS5(table, fnvHash(station), station, temp, options)
+			}
+			rest = rest[n:]
+
+			stat, _ := table.get(fnvHash(station), station)
+			if merged == nil {
+				merged = stat
+			} else {
+				mergeS5(merged, stat)
+			}
+		}
+		stat := StationStats{Sum: merged.sum, Count: int64(merged.count), SumSquares: merged.squares}
+
+		// Two passes, in exact rationals: the mean, then the squared deviations.
+		mean := new(big.Rat)
+		for _, temp := range temps {
+			mean.Add(mean, big.NewRat(int64(temp), 10))
+		}
+		mean.Quo(mean, big.NewRat(int64(count), 1))
+		deviations := new(big.Rat)
+		for _, temp := range temps {
+			d := new(big.Rat).Sub(big.NewRat(int64(temp), 10), mean)
+			deviations.Add(deviations, d.Mul(d, d))
+		}
+		want, _ := deviations.Quo(deviations, big.NewRat(int64(count), 1)).Float64()
+
+		if got := stat.Variance(); got != want {
+			t.Errorf("%d measurements: Variance() = %v, want %v", count, got, want)
+		}
+		if got := stat.Stddev(); got != math.Sqrt(want) {
+			t.Errorf("%d measurements: Stddev() = %v, want %v", count, got, math.Sqrt(want))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package brc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type weatherStationStats struct {
+	min, max, sum float64
+	count         int
+}
+
+type weatherData struct {
+	data map[string]*weatherStationStats
+}
+
+func newWeatherData() weatherData {
+	return weatherData{data: make(map[string]*weatherStationStats)}
+}
+
+// newLineScanner returns a scanner of the lines of r without their newline,
+// unlike bufio.ScanLines keeping a '\r' before it and without a limit on
+// their length, so malformed lines are rejected like by the other parsers.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, math.MaxInt)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	return scanner
+}
+
+func solution1(ctx context.Context, input Input) (Result, error) {
+	rejects, err := newLineRejecter(input)
+	if err != nil {
+		return Result{}, err
+	}
+
+	file, err := openInput(input)
+	if err != nil {
+		return Result{}, err
+	}
+	defer file.Close()
+
+	weatherData := newWeatherData()
+
+	scanner := newLineScanner(contextReader{ctx, file})
+	offset, lineNumber := int64(0), int64(0)
+
+	for scanner.Scan() {
+		lineNumber++
+		lineOffset := offset
+		offset += int64(len(scanner.Bytes())) + 1
+		if reason := malformedReason(scanner.Bytes()); reason != "" {
+			if err := rejects.reject(scanner.Bytes(), lineOffset, lineNumber, reason); err != nil {
+				return Result{}, err
+			}
+			continue
+		}
+
+		line := scanner.Text()
+
+		row := strings.Split(line, ";")
+
+		if len(row) > 0 {
+			station := row[0]
+			tempStr := row[1]
+
+			temp, err := strconv.ParseFloat(tempStr, 64)
+			if err != nil {
+				return Result{}, err
+			}
+
+			if stat := weatherData.data[station]; stat != nil {
+				if stat.min > temp {
+					stat.min = temp
+				}
+
+				if stat.max < temp {
+					stat.max = temp
+				}
+
+				stat.count++
+				stat.sum += temp
+			} else {
+				weatherData.data[station] = &weatherStationStats{
+					min:   temp,
+					max:   temp,
+					count: 1,
+					sum:   temp,
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Result{}, err
+	}
+
+	return newResult(weatherData.data, rejects.rejected()), nil
+}
@@ -0,0 +1,55 @@
+package brc
+
+import (
+	"context"
+)
+
+func parseTemperature(temp []byte) float64 {
+	negative := false
+	idx := 0
+
+	if temp[idx] == '-' {
+		negative = true
+		idx++
+	}
+
+	// Parse the first digit
+	tempFlt := float64(temp[idx] - '0')
+	idx++
+
+	// Parse the second digit (optional).
+	if temp[idx] != '.' {
+		tempFlt = tempFlt*10 + float64(temp[idx]-'0')
+		idx++
+	}
+	idx++
+
+	tempFlt += float64(temp[idx]-'0') / 10 // convert to decimal
+	if negative {
+		tempFlt = -tempFlt
+	}
+	return tempFlt
+}
+
+func solution2(ctx context.Context, input Input) (Result, error) {
+	rejects, err := newLineRejecter(input)
+	if err != nil {
+		return Result{}, err
+	}
+
+	file, err := openInput(input)
+	if err != nil {
+		return Result{}, err
+	}
+	defer file.Close()
+
+	table := newStationTable[weatherStationStats](defaultTableBuckets)
+	err = forEachBlock(contextReader{ctx, file}, 0, 1, func(b block) error {
+		return processLinesS2(b, table, rejects)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	return newResult(table.toMap(), rejects.rejected()), nil
+}
@@ -0,0 +1,175 @@
+package brc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type fileChunk struct {
+	size, offset int64
+}
+
+// splitFile splits the size bytes of r into at most count chunks of about
+// the same size, each ending with a newline or at the end of the data.
+//
+// Every nominal boundary is moved forward to just after the next newline, so
+// the chunks are contiguous, never overlap and cover all the data whatever
+// the length of the lines. Data with fewer lines than count gives fewer
+// chunks, never empty ones, and no data gives none.
+func splitFile(r io.ReaderAt, size int64, count int) ([]fileChunk, error) {
+	chunkSize := max(size/int64(count), 1)
+	chunks := make([]fileChunk, 0, count)
+	offset := int64(0)
+
+	for offset < size {
+		end := offset + chunkSize
+		if end >= size || len(chunks) == count-1 {
+			end = size
+		} else {
+			var err error
+			end, err = nextLine(r, end-1, size)
+			if err != nil {
+				return nil, err
+			}
+		}
+		chunks = append(chunks, fileChunk{end - offset, offset})
+		offset = end
+	}
+	return chunks, nil
+}
+
+// nextLine returns the offset just after the first newline of r at or after
+// offset, or size if there is none.
+func nextLine(r io.ReaderAt, offset, size int64) (int64, error) {
+	const maxLineLength = 100 + len(";-99.9\n") // longest valid line, lines can be longer
+
+	buf := make([]byte, maxLineLength)
+	for offset < size {
+		n, err := r.ReadAt(buf[:min(int64(len(buf)), size-offset)], offset)
+		if nl := bytes.IndexByte(buf[:n], '\n'); nl >= 0 {
+			return offset + int64(nl) + 1, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if n == 0 {
+			return 0, fmt.Errorf("unexpected end of data at offset %d", offset)
+		}
+		offset += int64(n)
+	}
+	return size, nil
+}
+
+// aggregateLinesS1 aggregates the lines of b into weatherData, passing
+// malformed lines to rejects.
+func aggregateLinesS1(b block, weatherData weatherData, rejects *lineRejecter) error {
+	scanner := newLineScanner(bytes.NewReader(b.data))
+	pos := 0
+	for scanner.Scan() {
+		lineStart := pos
+		pos += len(scanner.Bytes()) + 1
+		if reason := malformedReason(scanner.Bytes()); reason != "" {
+			if err := rejects.reject(scanner.Bytes(), b.offset+int64(lineStart), b.lineAt(lineStart), reason); err != nil {
+				return err
+			}
+			continue
+		}
+
+		line := scanner.Text()
+		row := strings.Split(line, ";")
+
+		if len(row) > 0 {
+			station := row[0]
+			tempStr := row[1]
+
+			temp, err := strconv.ParseFloat(tempStr, 64)
+			if err != nil {
+				return err
+			}
+
+			if stat := weatherData.data[station]; stat != nil {
+				if stat.min > temp {
+					stat.min = temp
+				}
+
+				if stat.max < temp {
+					stat.max = temp
+				}
+
+				stat.count++
+				stat.sum += temp
+			} else {
+				weatherData.data[station] = &weatherStationStats{
+					min:   temp,
+					max:   temp,
+					count: 1,
+					sum:   temp,
+				}
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+func processChuckS1(blocks blockSource, rejects *lineRejecter) (map[string]*weatherStationStats, error) {
+	weatherData := newWeatherData()
+	err := blocks(func(b block) error {
+		return aggregateLinesS1(b, weatherData, rejects)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return weatherData.data, nil
+}
+
+func solution3(ctx context.Context, input Input) (Result, error) {
+	rejects, err := newLineRejecter(input)
+	if err != nil {
+		return Result{}, err
+	}
+
+	resultsChan, workers, stop, err := startWorkers(ctx, input, func(blocks blockSource) (map[string]*weatherStationStats, error) {
+		return processChuckS1(blocks, rejects)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	defer stop() // stops the remaining workers when returning early on error
+
+	workerStats := make([]WorkerStats, 0, workers)
+	weatherData := make(map[string]*weatherStationStats)
+	for i := 0; i < workers; i++ {
+		result := <-resultsChan
+		if result.err != nil {
+			return Result{}, result.err
+		}
+		workerStats = append(workerStats, result.worker)
+
+		for station, stat := range result.stats {
+			ts, ok := weatherData[station]
+			if !ok {
+				weatherData[station] = &weatherStationStats{
+					min:   stat.min,
+					max:   stat.max,
+					sum:   stat.sum,
+					count: stat.count,
+				}
+				continue
+			}
+
+			ts.min = min(ts.min, stat.min)
+			ts.max = max(ts.max, stat.max)
+			ts.sum += stat.sum
+			ts.count += stat.count
+			weatherData[station] = ts
+		}
+	}
+
+	result := newResult(weatherData, rejects.rejected())
+	result.Workers = workerStats
+	return result, nil
+}
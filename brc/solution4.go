@@ -0,0 +1,135 @@
+package brc
+
+import (
+	"context"
+)
+
+// processLinesS2 aggregates the lines of b into table, passing malformed
+// lines to rejects.
+func processLinesS2(b block, table *stationTable[weatherStationStats], rejects *lineRejecter) error {
+	chunk := b.data
+
+	for {
+		// FNV-1 constants from hash/fnv
+		const (
+			offset64 = 14695981039346656037
+			prime64  = 1099511628211
+		)
+
+		// Hash the station name and look for ';' (or the end of a line missing it)
+		hash := uint64(offset64)
+		i := 0
+		for ; i < len(chunk); i++ {
+			c := chunk[i]
+			if c == ';' || c == '\n' {
+				break
+			}
+			hash ^= uint64(c)
+			hash *= prime64
+		}
+		if i == len(chunk) {
+			break
+		}
+
+		station, tempBytes := chunk[:i], chunk[i+1:]
+		if reason := malformedFields(chunk[i], station, tempBytes); reason != "" {
+			var err error
+			if chunk, err = rejects.rejectLine(b, chunk, reason); err != nil {
+				return err
+			}
+			continue
+		}
+
+		negative := false
+		idx := 0
+
+		if tempBytes[idx] == '-' {
+			negative = true
+			idx++
+		}
+
+		// Parse the first digit
+		tempFlt := float64(tempBytes[idx] - '0')
+		idx++
+
+		// Parse the second digit (optional).
+		if tempBytes[idx] != '.' {
+			tempFlt = tempFlt*10 + float64(tempBytes[idx]-'0')
+			idx++
+		}
+		idx++
+
+		tempFlt += float64(tempBytes[idx]-'0') / 10 // convert to decimal
+		idx += 2
+		if negative {
+			tempFlt = -tempFlt
+		}
+		chunk = tempBytes[idx:]
+
+		stat, added := table.get(hash, station)
+		if added {
+			*stat = weatherStationStats{
+				min:   tempFlt,
+				max:   tempFlt,
+				sum:   tempFlt,
+				count: 1,
+			}
+			continue
+		}
+		stat.min = min(stat.min, tempFlt)
+		stat.max = max(stat.max, tempFlt)
+		stat.sum += tempFlt
+		stat.count++
+	}
+	return nil
+}
+
+func processChuckS2(blocks blockSource, rejects *lineRejecter) (*stationTable[weatherStationStats], error) {
+	table := newStationTable[weatherStationStats](defaultTableBuckets)
+	err := blocks(func(b block) error {
+		return processLinesS2(b, table, rejects)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func solution4(ctx context.Context, input Input) (Result, error) {
+	rejects, err := newLineRejecter(input)
+	if err != nil {
+		return Result{}, err
+	}
+
+	resultsChan, workers, stop, err := startWorkers(ctx, input, func(blocks blockSource) (*stationTable[weatherStationStats], error) {
+		return processChuckS2(blocks, rejects)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	defer stop() // stops the remaining workers when returning early on error
+
+	workerStats := make([]WorkerStats, 0, workers)
+	weatherData := newStationTable[weatherStationStats](defaultTableBuckets)
+	for i := 0; i < workers; i++ {
+		result := <-resultsChan
+		if result.err != nil {
+			return Result{}, result.err
+		}
+		workerStats = append(workerStats, result.worker)
+
+		weatherData.merge(result.stats, mergeS2)
+	}
+
+	result := newResult(weatherData.toMap(), rejects.rejected())
+	result.Workers = workerStats
+	return result, nil
+}
+
+// mergeS2 combines the stats of the same station from two workers.
+func mergeS2(dst, src *weatherStationStats) {
+	dst.min = min(dst.min, src.min)
+	dst.max = max(dst.max, src.max)
+	dst.sum += src.sum
+	dst.count += src.count
+}
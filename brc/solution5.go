@@ -0,0 +1,237 @@
+package brc
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+type s5WeatherStationStats struct {
+	min, max, count int32
+	sum             int64
+	squares         int64      // sum of the squared tenths, exact for the variance
+	below, above    int32      // measurements below and above Stats.Outliers
+	histogram       *Histogram // with Stats.Percentiles, nil otherwise
+}
+
+// s5Options are the options of Input applied by processLinesS5 and
+// processLinesS6 while aggregating.
+type s5Options struct {
+	stats    Stats
+	stations *stationTable[struct{}] // the only stations aggregated, all when nil
+	// low and high bound the aggregated temperatures, below and above
+	// the ones counted as outliers, all in tenths of a degree and
+	// defaulting to values no temperature is out of.
+	low, high    int32
+	below, above int32
+}
+
+func newS5Options(input Input, hash func(station []byte) uint64) *s5Options {
+	options := &s5Options{
+		stats:    input.Stats,
+		stations: newStationSet(input.Stations, hash),
+		low:      minTenths,
+		high:     maxTenths,
+		below:    minTenths,
+		above:    maxTenths,
+	}
+	if input.Range != nil {
+		options.low = clampTenths(input.Range.Min)
+		options.high = clampTenths(input.Range.Max)
+	}
+	if input.Stats.Outliers != nil {
+		options.below = clampTenths(input.Stats.Outliers.Min)
+		options.above = clampTenths(input.Stats.Outliers.Max)
+	}
+	return options
+}
+
+// clampTenths converts tenths to int32, saturating outside of the
+// temperatures the parsers accept.
+func clampTenths(tenths int64) int32 {
+	return int32(min(max(tenths, minTenths-1), maxTenths+1))
+}
+
+// fnvHash is the FNV-1a hash of station computed inline by processLinesS5.
+func fnvHash(station []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(station)
+	return h.Sum64()
+}
+
+// processLinesS5 aggregates the lines of b into table, passing malformed
+// lines to rejects.
+func processLinesS5(b block, table *stationTable[s5WeatherStationStats], rejects *lineRejecter, options *s5Options) error {
+	chunk := b.data
+
+	for {
+		// FNV-1 constants from hash/fnv
+		const (
+			offset64 = 14695981039346656037
+			prime64  = 1099511628211
+		)
+
+		// Hash the station name and look for ';' (or the end of a line missing it)
+		hash := uint64(offset64)
+		i := 0
+		for ; i < len(chunk); i++ {
+			c := chunk[i]
+			if c == ';' || c == '\n' {
+				break
+			}
+			hash ^= uint64(c)
+			hash *= prime64
+		}
+		if i == len(chunk) {
+			break
+		}
+
+		station, tempBytes := chunk[:i], chunk[i+1:]
+		if reason := malformedFields(chunk[i], station, tempBytes); reason != "" {
+			var err error
+			if chunk, err = rejects.rejectLine(b, chunk, reason); err != nil {
+				return err
+			}
+			continue
+		}
+
+		negative := false
+		idx := 0
+
+		if tempBytes[idx] == '-' {
+			negative = true
+			idx++
+		}
+
+		// Parse the first digit
+		tempFlt := int32(tempBytes[idx] - '0')
+		idx++
+
+		// Parse the second digit (optional).
+		if tempBytes[idx] != '.' {
+			tempFlt = tempFlt*10 + int32(tempBytes[idx]-'0')
+			idx++
+		}
+		idx++
+
+		tempFlt = tempFlt*10 + int32(tempBytes[idx]-'0')
+		idx += 2
+		if negative {
+			tempFlt = -tempFlt
+		}
+		chunk = tempBytes[idx:]
+
+		aggregateS5(table, hash, station, tempFlt, options)
+	}
+	return nil
+}
+
+// aggregateS5 adds the temperature temp of station, hash being its hash, to
+// table unless options filter it out.
+func aggregateS5(table *stationTable[s5WeatherStationStats], hash uint64, station []byte, temp int32, options *s5Options) {
+	if options.stations != nil && !options.stations.contains(hash, station) {
+		return
+	}
+	if temp < options.low || temp > options.high {
+		return // outside of Input.Range
+	}
+	stat, added := table.get(hash, station)
+	if added {
+		*stat = s5WeatherStationStats{min: temp, max: temp}
+		if options.stats.Percentiles {
+			stat.histogram = new(Histogram)
+		}
+	}
+	if stat.histogram != nil {
+		stat.histogram.add(temp)
+	}
+	if temp < options.below {
+		stat.below++
+	}
+	if temp > options.above {
+		stat.above++
+	}
+	stat.min = min(stat.min, temp)
+	stat.max = max(stat.max, temp)
+	stat.sum += int64(temp)
+	stat.squares += int64(temp) * int64(temp)
+	stat.count++
+}
+
+// s5LineProcessor aggregates the lines of b into table like processLinesS5.
+type s5LineProcessor func(b block, table *stationTable[s5WeatherStationStats], rejects *lineRejecter, options *s5Options) error
+
+func processChuckS5(blocks blockSource, rejects *lineRejecter, options *s5Options, processLines s5LineProcessor) (*stationTable[s5WeatherStationStats], error) {
+	table := newStationTable[s5WeatherStationStats](defaultTableBuckets)
+	err := blocks(func(b block) error {
+		return processLines(b, table, rejects, options)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func solution5(ctx context.Context, input Input) (Result, error) {
+	return solveS5(ctx, input, fnvHash, processLinesS5)
+}
+
+// solveS5 runs processLines on the workers and merges their tables, hash
+// being the hash of station names processLines computes.
+func solveS5(ctx context.Context, input Input, hash func(station []byte) uint64, processLines s5LineProcessor) (Result, error) {
+	rejects, err := newLineRejecter(input)
+	if err != nil {
+		return Result{}, err
+	}
+
+	options := newS5Options(input, hash)
+	resultsChan, workers, stop, err := startWorkers(ctx, input, func(blocks blockSource) (*stationTable[s5WeatherStationStats], error) {
+		return processChuckS5(blocks, rejects, options, processLines)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	defer stop() // stops the remaining workers when returning early on error
+
+	workerStats := make([]WorkerStats, 0, workers)
+	weatherData := newStationTable[s5WeatherStationStats](defaultTableBuckets)
+	for i := 0; i < workers; i++ {
+		result := <-resultsChan
+		if result.err != nil {
+			return Result{}, result.err
+		}
+		workerStats = append(workerStats, result.worker)
+
+		weatherData.merge(result.stats, mergeS5)
+	}
+
+	stations := make([]StationStats, 0, weatherData.size)
+	weatherData.each(func(station []byte, stat *s5WeatherStationStats) {
+		stations = append(stations, StationStats{
+			Station:    string(station),
+			Min:        int64(stat.min),
+			Max:        int64(stat.max),
+			Sum:        stat.sum,
+			Count:      int64(stat.count),
+			SumSquares: stat.squares,
+			Below:      int64(stat.below),
+			Above:      int64(stat.above),
+			Histogram:  stat.histogram,
+		})
+	})
+	sortStations(stations)
+	return Result{Stations: stations, Rejected: rejects.rejected(), Workers: workerStats, Stats: input.Stats}, nil
+}
+
+// mergeS5 combines the stats of the same station from two workers.
+func mergeS5(dst, src *s5WeatherStationStats) {
+	dst.min = min(dst.min, src.min)
+	dst.max = max(dst.max, src.max)
+	dst.sum += src.sum
+	dst.squares += src.squares
+	dst.below += src.below
+	dst.above += src.above
+	dst.count += src.count
+	if src.histogram != nil {
+		dst.histogram.merge(src.histogram)
+	}
+}
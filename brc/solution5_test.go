@@ -0,0 +1,121 @@
+package brc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestTemperatureRange checks the temperatures solution5 and solution6
+// aggregate with Input.Range, both inclusive and in tenths of a degree, and
+// count outside of Stats.Outliers. Bounds past the valid temperatures are
+// clamped. The counts are also merged from the tables of four workers, as
+// the solvers may hand all the segments to one.
+func TestTemperatureRange(t *testing.T) {
+	temps := []int64{-999, -600, -599, -301, -300, 0, 450, 451, 600, 601, 999}
+	var data []byte
+	for i := 0; i < 500; i++ {
+		for _, temp := range temps {
+			data = fmt.Appendf(data, "Oslo;%s\n", appendTenths(nil, temp))
+		}
+	}
+	path := filepath.Join(t.TempDir(), "measurements.txt")
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	unbounded := TemperatureRange{Min: math.MinInt64, Max: math.MaxInt64}
+	tests := []struct {
+		name            string
+		bounds          *TemperatureRange
+		outliers        *TemperatureRange
+		min, max, count int64
+		below, above    int64
+	}{
+		{"no range", nil, nil, -999, 999, 11, 0, 0},
+		{"inclusive bounds", &TemperatureRange{-600, 600}, nil, -600, 600, 8, 0, 0},
+		{"lower bound only", &TemperatureRange{-300, math.MaxInt64}, nil, -300, 999, 7, 0, 0},
+		{"upper bound only", &TemperatureRange{math.MinInt64, 450}, nil, -999, 450, 7, 0, 0},
+		{"bounds past the valid temperatures", &TemperatureRange{-5000, 5000}, nil, -999, 999, 11, 0, 0},
+		{"bounds on the valid temperatures", &TemperatureRange{-999, 999}, nil, -999, 999, 11, 0, 0},
+		{"bound above the valid temperatures", &TemperatureRange{1000, math.MaxInt64}, nil, 0, 0, 0, 0, 0},
+		{"bound below the valid temperatures", &TemperatureRange{math.MinInt64, -1000}, nil, 0, 0, 0, 0, 0},
+		{"inclusive outliers", nil, &TemperatureRange{-300, 450}, -999, 999, 11, 4, 4},
+		{"lower outlier only", nil, &TemperatureRange{-600, math.MaxInt64}, -999, 999, 11, 1, 0},
+		{"outliers past the valid temperatures", nil, &TemperatureRange{-5000, 5000}, -999, 999, 11, 0, 0},
+		{"outliers above the valid temperatures", nil, &TemperatureRange{1000, 2000}, -999, 999, 11, 11, 0},
+		{"unbounded outliers", nil, &unbounded, -999, 999, 11, 0, 0},
+		{"outliers of the kept temperatures", &TemperatureRange{-600, 600}, &TemperatureRange{-300, 450}, -600, 600, 8, 3, 2},
+	}
+	check := func(t *testing.T, min, max, count, below, above int64, want int) {
+		t.Helper()
+		tt := tests[want]
+		if min != tt.min || max != tt.max || count != 500*tt.count || below != 500*tt.below || above != 500*tt.above {
+			t.Errorf("got %d/%d count %d below %d above %d, want %d/%d count %d below %d above %d",
+				min, max, count, below, above, tt.min, tt.max, 500*tt.count, 500*tt.below, 500*tt.above)
+		}
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	lines = lines[:len(lines)-1] // empty after the last newline
+	for name, processLines := range map[string]s5LineProcessor{"processLinesS5": processLinesS5, "processLinesS6": processLinesS6} {
+		for i, tt := range tests {
+			t.Run(name+"/merged workers/"+tt.name, func(t *testing.T) {
+				options := newS5Options(Input{Range: tt.bounds, Stats: Stats{Outliers: tt.outliers}}, fnvHash)
+				merged := newStationTable[s5WeatherStationStats](4)
+				for w := 0; w < 4; w++ {
+					table := newStationTable[s5WeatherStationStats](4)
+					b := block{data: bytes.Join(lines[w*len(lines)/4:(w+1)*len(lines)/4], nil), line: 1}
+					if err := processLines(b, table, &lineRejecter{policy: PolicyStrict}, options); err != nil {
+						t.Fatal(err)
+					}
+					merged.merge(table, mergeS5)
+				}
+
+				if tt.count == 0 {
+					if merged.size != 0 {
+						t.Fatalf("got %d stations, want none", merged.size)
+					}
+					return
+				}
+				got := onlyStats(merged)
+				check(t, int64(got.min), int64(got.max), int64(got.count), int64(got.below), int64(got.above), i)
+			})
+		}
+	}
+
+	ctx := context.Background()
+	for _, name := range []string{"solution5", "solution6"} {
+		solver, _ := Lookup(name)
+		for _, in := range solverInputs {
+			for i, tt := range tests {
+				t.Run(name+"/"+in.name+"/"+tt.name, func(t *testing.T) {
+					input := Input{FilePath: path, IO: in.io, Workers: in.workers, SegmentSize: in.segmentSize, Range: tt.bounds, Stats: Stats{Outliers: tt.outliers}}
+					if in.reader {
+						input.FilePath, input.Reader = "", bytes.NewReader(data)
+					}
+					result, err := solver.Solve(ctx, input)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if tt.count == 0 {
+						if len(result.Stations) != 0 {
+							t.Fatalf("got %v, want no station", result.Stations)
+						}
+						return
+					}
+					if len(result.Stations) != 1 {
+						t.Fatalf("got %d stations, want Oslo", len(result.Stations))
+					}
+					got := result.Stations[0]
+					check(t, got.Min, got.Max, got.Count, got.Below, got.Above, i)
+				})
+			}
+		}
+	}
+}
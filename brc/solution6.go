@@ -0,0 +1,122 @@
+package brc
+
+import (
+	"context"
+	"encoding/binary"
+	"math/bits"
+)
+
+// SWAR (SIMD within a register) helpers working on 8 bytes of a line at once,
+// read as a little endian uint64 so the first byte is the lowest one.
+const (
+	swarOnes = 0x0101010101010101
+	swarHigh = 0x8080808080808080
+)
+
+// zeroBytes sets the high bit of the zero bytes of x. Bytes above the first
+// zero byte may be set wrongly by the borrow, so only its lowest set bit is
+// reliable.
+func zeroBytes(x uint64) uint64 {
+	return (x - swarOnes) &^ x & swarHigh
+}
+
+// delimiterMask marks the ';' and '\n' bytes of word, its lowest set bit
+// being the first of them.
+func delimiterMask(word uint64) uint64 {
+	return zeroBytes(word^(';'*swarOnes)) | zeroBytes(word^('\n'*swarOnes))
+}
+
+// loadWord reads 8 bytes of b, padding with zeros past its end.
+func loadWord(b []byte) uint64 {
+	if len(b) >= 8 {
+		return binary.LittleEndian.Uint64(b)
+	}
+	var buf [8]byte
+	copy(buf[:], b)
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+// Hashing of station names 8 bytes at a time: hashWord mixes in every word
+// of the name, the last one with the bytes past the name zeroed (all of it
+// when the length is a multiple of 8), and hashFinish spreads the bits so
+// the table can index with the low ones.
+const hashPrime = 0x9e3779b97f4a7c15
+
+func hashWord(h, word uint64) uint64 {
+	return bits.RotateLeft64((h^word)*hashPrime, 29)
+}
+
+func hashFinish(h uint64, length int) uint64 {
+	h ^= uint64(length)
+	h *= hashPrime
+	return h ^ h>>32
+}
+
+// nameHash hashes the station name of the given length at the start of line,
+// the hash processLinesS6 computes while looking for the delimiter.
+func nameHash(line []byte, length int) uint64 {
+	var h uint64
+	i := 0
+	for ; i+8 <= length; i += 8 {
+		h = hashWord(h, binary.LittleEndian.Uint64(line[i:]))
+	}
+	h = hashWord(h, loadWord(line[i:])&(1<<(8*(length-i))-1)) // line goes on with the temperature
+	return hashFinish(h, length)
+}
+
+// parseTemperatureWord parses a "-?d?d.d\n" temperature from the word
+// starting with it without branching, returning it in tenths of a degree
+// and its length including the newline.
+func parseTemperatureWord(word uint64) (int32, int) {
+	// The '.' is the only byte of the first four without the 0x10 bit,
+	// digits and '-' differ there, so it tells where the digits are.
+	dot := bits.TrailingZeros64(^word & 0x10101000)
+	negative := int64(^word<<59) >> 63 // -1 when the first byte is '-'
+	unsigned := word &^ (uint64(negative) & 0xff)
+	// Align the digits as 0xUU00TTHH00 and sum them with a single multiply.
+	digits := (unsigned << (28 - dot)) & 0x0f000f0f00
+	abs := int64((digits * 0x640a0001) >> 32 & 0x3ff)
+	return int32((abs ^ negative) - negative), dot>>3 + 3
+}
+
+// processLinesS6 is processLinesS5 reading 8 bytes at a time to find the
+// delimiters and parse the temperature.
+func processLinesS6(b block, table *stationTable[s5WeatherStationStats], rejects *lineRejecter, options *s5Options) error {
+	chunk := b.data
+
+	for len(chunk) > 0 {
+		// Find the first ';' or '\n' and hash the name from the same words,
+		// blocks always end with a newline
+		var hash uint64
+		i := 0
+		for {
+			word := loadWord(chunk[i:])
+			if mask := delimiterMask(word); mask != 0 {
+				n := bits.TrailingZeros64(mask) >> 3
+				hash = hashFinish(hashWord(hash, word&(1<<(8*n)-1)), i+n)
+				i += n
+				break
+			}
+			hash = hashWord(hash, word)
+			i += 8
+		}
+
+		station, tempBytes := chunk[:i], chunk[i+1:]
+		if reason := malformedFields(chunk[i], station, tempBytes); reason != "" {
+			var err error
+			if chunk, err = rejects.rejectLine(b, chunk, reason); err != nil {
+				return err
+			}
+			continue
+		}
+
+		temp, n := parseTemperatureWord(loadWord(tempBytes))
+		chunk = tempBytes[n:]
+		aggregateS5(table, hash, station, temp, options)
+	}
+	return nil
+}
+
+func solution6(ctx context.Context, input Input) (Result, error) {
+	return solveS5(ctx, input, func(station []byte) uint64 { return nameHash(station, len(station)) }, processLinesS6)
+}
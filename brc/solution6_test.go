@@ -0,0 +1,44 @@
+package brc
+
+import (
+	"fmt"
+	"testing"
+)
+
+// sharedAffixNames returns count names differing only in the middle, with
+// lengths around the 8-byte words nameHash reads.
+func sharedAffixNames(count int) []string {
+	names := make([]string, count)
+	for i := range names {
+		names[i] = fmt.Sprintf("Station %05d of the network", i)
+	}
+	return names
+}
+
+func TestNameHash(t *testing.T) {
+	// processLinesS6 hashes the names on the line while looking for the
+	// delimiter, nameHash the names alone, e.g. for the Input.Stations set
+	var data []byte
+	names := append(sharedAffixNames(100), "A", "Oslo", "Hamburg", "Helsinki", "Reykjavík", "Ouagadougou", "Station 00001 of the networkX")
+	for _, name := range names {
+		data = append(data, name+";12.3\n"...)
+	}
+	table := newStationTable[s5WeatherStationStats](4)
+	if err := processLinesS6(block{data: data, line: 1}, table, &lineRejecter{policy: PolicyStrict}, newS5Options(Input{}, fnvHash)); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if !table.contains(nameHash([]byte(name), len(name)), []byte(name)) {
+			t.Errorf("nameHash(%q) differs from the hash of processLinesS6", name)
+		}
+	}
+
+	// names differing in any word must spread over the table
+	buckets := make(map[uint64]bool)
+	for _, name := range sharedAffixNames(10_000) {
+		buckets[nameHash([]byte(name), len(name))&(defaultTableBuckets-1)] = true
+	}
+	if len(buckets) < 9_000 {
+		t.Errorf("10000 names sharing a prefix and suffix hash to %d buckets", len(buckets))
+	}
+}
@@ -0,0 +1,153 @@
+// Package brc contains solutions to the One Billion Row Challenge.
+package brc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// IOMode selects how the parallel solutions read the input file.
+type IOMode string
+
+const (
+	// IOBuffered reads each chunk through its own file handle and a 1MB buffer.
+	IOBuffered IOMode = "buffered"
+	// IOMmap maps the file once and parses each chunk in place without copying.
+	IOMmap IOMode = "mmap"
+)
+
+// Policy decides what happens to malformed lines, anything that is not
+// "station;temperature" with a 1 to 100 byte name and a -99.9 to 99.9
+// temperature with exactly one fractional digit.
+type Policy string
+
+const (
+	// PolicyStrict fails with a *MalformedLineError on the first malformed line.
+	PolicyStrict Policy = "strict"
+	// PolicySkip drops malformed lines and counts them in Result.Rejected.
+	PolicySkip Policy = "skip"
+	// PolicyReport is PolicySkip and writes the dropped lines to Input.Rejects.
+	PolicyReport Policy = "report"
+)
+
+// Stats selects the statistics computed beyond min, mean and max, only by
+// the solvers supporting them (solution5 and solution6).
+type Stats struct {
+	// Percentiles keeps a Histogram per station and worker, 8KB each, for
+	// the median, p90, p99 and mode.
+	Percentiles bool
+	// Variance reports the variance and standard deviation, computed from
+	// an exact sum of the squared tenths.
+	Variance bool
+	// Outliers counts per station the measurements below its Min and
+	// above its Max.
+	Outliers *TemperatureRange
+}
+
+// TemperatureRange bounds temperatures in tenths of a degree, inclusive.
+type TemperatureRange struct {
+	Min, Max int64
+}
+
+// ParseStats parses a comma separated list of statistics, e.g.
+// "percentiles,variance".
+func ParseStats(list string) (Stats, error) {
+	var stats Stats
+	for _, name := range strings.Split(list, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "percentiles":
+			stats.Percentiles = true
+		case "variance":
+			stats.Variance = true
+		default:
+			return Stats{}, fmt.Errorf("invalid statistic %q, should be percentiles or variance", name)
+		}
+	}
+	return stats, nil
+}
+
+// Input describes the measurements to aggregate and how to read them.
+type Input struct {
+	FilePath string
+	// Reader is read instead of FilePath when set, e.g. stdin or a network
+	// stream. The parallel solutions split it into blocks for their workers.
+	Reader io.Reader
+	IO     IOMode // only used by the parallel solutions, defaults to IOBuffered
+	Policy Policy // defaults to PolicyStrict
+	// Workers is the number of goroutines of the parallel solutions,
+	// defaults to runtime.NumCPU().
+	Workers int
+	// SegmentSize is the size in bytes of the file segments the workers of
+	// the parallel solutions take one at a time, defaults to 8MB.
+	SegmentSize int64
+	// Rejects receives the malformed lines, one per line, with PolicyReport.
+	Rejects io.Writer
+	Stats   Stats
+	// Stations are the only stations in the Result. solution5 and
+	// solution6 skip the lines of other stations before aggregating them
+	// when it only has exact names.
+	Stations StationFilter
+	// Range drops the measurements outside of it before aggregating them,
+	// in solution5 and solution6. All of them are kept when nil.
+	Range *TemperatureRange
+}
+
+// Solver aggregates a weather station measurements file into per-station stats.
+type Solver interface {
+	Name() string
+	Description() string
+	Solve(ctx context.Context, input Input) (Result, error)
+}
+
+type solverFunc struct {
+	name, description string
+	solve             func(ctx context.Context, input Input) (Result, error)
+	extended          bool // supports Input.Stats and Input.Range
+}
+
+func (s solverFunc) Name() string        { return s.name }
+func (s solverFunc) Description() string { return s.description }
+
+func (s solverFunc) Solve(ctx context.Context, input Input) (Result, error) {
+	if (input.Stats != (Stats{}) || input.Range != nil) && !s.extended {
+		return Result{}, fmt.Errorf("%s does not compute extra statistics or filter temperatures, use solution5 or solution6", s.name)
+	}
+	result, err := s.solve(ctx, input)
+	if err != nil {
+		return Result{}, err
+	}
+	return result.Filter(input.Stations), nil
+}
+
+var registry = []Solver{
+	solverFunc{"solution1", "bufio.Scanner, strings.Split, strconv.ParseFloat and a Go map", solution1, false},
+	solverFunc{"solution2", "1MB read buffer, inlined temperature parsing and a linear probing hash table", solution2, false},
+	solverFunc{"solution3", "solution1 on file segments pulled by a worker per CPU in parallel", solution3, false},
+	solverFunc{"solution4", "solution2 on file segments pulled by a worker per CPU in parallel", solution4, false},
+	solverFunc{"solution5", "solution4 with integer temperatures in tenths of a degree", solution5, true},
+	solverFunc{"solution6", "solution5 with SWAR delimiter search, branchless temperature parsing and a word based hash", solution6, true},
+}
+
+// Solvers returns all registered solvers in registration order.
+func Solvers() []Solver {
+	return append([]Solver(nil), registry...)
+}
+
+// Lookup returns the registered solver with the given name.
+func Lookup(name string) (Solver, bool) {
+	for _, s := range registry {
+		if s.Name() == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// Register adds a solver to the registry, e.g. to benchmark it against the built-in solutions.
+// It is not safe to call concurrently with the other registry functions.
+func Register(s Solver) {
+	registry = append(registry, s)
+}
@@ -0,0 +1,173 @@
+package brc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// solverInputs are the ways to read a file every solver should agree on.
+var solverInputs = []struct {
+	name        string
+	io          IOMode
+	reader      bool
+	workers     int
+	segmentSize int64
+}{
+	{"buffered", IOBuffered, false, 0, 0},
+	{"mmap", IOMmap, false, 0, 0},
+	{"reader", IOBuffered, true, 0, 0},
+	{"buffered/small segments", IOBuffered, false, 2*runtime.NumCPU() + 1, 1024},
+	{"mmap/small segments", IOMmap, false, 2*runtime.NumCPU() + 1, 1024},
+}
+
+// solveAll runs every solver on the file at path read in every way and
+// calls check with each result.
+func solveAll(t *testing.T, path string, check func(t *testing.T, result Result)) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, solver := range Solvers() {
+		for _, in := range solverInputs {
+			t.Run(solver.Name()+"/"+in.name, func(t *testing.T) {
+				input := Input{FilePath: path, IO: in.io, Workers: in.workers, SegmentSize: in.segmentSize}
+				if in.reader {
+					input.FilePath, input.Reader = "", bytes.NewReader(data)
+				}
+				result, err := solver.Solve(context.Background(), input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				check(t, result)
+			})
+		}
+	}
+}
+
+// TestGolden compares the output of every solver on testdata/*.txt with the
+// matching .out file.
+func TestGolden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no golden files in testdata")
+	}
+
+	for _, path := range paths {
+		want, err := os.ReadFile(strings.TrimSuffix(path, ".txt") + ".out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".txt"), func(t *testing.T) {
+			solveAll(t, path, func(t *testing.T, result Result) {
+				var got bytes.Buffer
+				if err := result.Write(&got); err != nil {
+					t.Fatal(err)
+				}
+				if got.String() != string(want) {
+					t.Errorf("got\n%.500s\nwant\n%.500s", got.String(), want)
+				}
+			})
+		})
+	}
+}
+
+// randomMeasurements returns lines of random stations and temperatures,
+// with or without a trailing newline.
+func randomMeasurements(r *rand.Rand, lines, stations int) []byte {
+	names := make([]string, stations)
+	alphabet := []rune("abcdefghijklmnopqrstuvwxyzABCXYZ éßøñ東北京ął-'")
+	for i := range names {
+		var name []rune
+		for n := 1 + r.Intn(30); len(name) < n; {
+			name = append(name, alphabet[r.Intn(len(alphabet))])
+		}
+		names[i] = string(name)
+	}
+
+	var buf bytes.Buffer
+	for i := 0; i < lines; i++ {
+		tenths := r.Intn(1999) - 999
+		sign := ""
+		if tenths < 0 {
+			sign, tenths = "-", -tenths
+		}
+		fmt.Fprintf(&buf, "%s;%s%d.%d\n", names[r.Intn(len(names))], sign, tenths/10, tenths%10)
+	}
+	if r.Intn(2) == 0 {
+		buf.Truncate(buf.Len() - 1)
+	}
+	return buf.Bytes()
+}
+
+// naiveStats aggregates the measurements line by line with strconv.
+func naiveStats(t *testing.T, data []byte) []StationStats {
+	stats := map[string]*StationStats{}
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		station, temp, _ := strings.Cut(line, ";")
+		degrees, err := strconv.ParseFloat(temp, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tenths := int64(math.Round(degrees * 10))
+		stat, ok := stats[station]
+		if !ok {
+			stat = &StationStats{Station: station, Min: tenths, Max: tenths}
+			stats[station] = stat
+		}
+		stat.Min = min(stat.Min, tenths)
+		stat.Max = max(stat.Max, tenths)
+		stat.Sum += tenths
+		stat.Count++
+	}
+
+	stations := make([]StationStats, 0, len(stats))
+	for _, stat := range stats {
+		stations = append(stations, *stat)
+	}
+	sort.Slice(stations, func(i, j int) bool { return stations[i].Station < stations[j].Station })
+	return stations
+}
+
+// TestDifferential compares every solver with a naive aggregation on random
+// generated files.
+func TestDifferential(t *testing.T) {
+	sizes := []struct{ lines, stations int }{{1, 1}, {7, 3}, {1000, 10}, {20000, 500}, {50000, 5000}}
+	for seed, size := range sizes {
+		r := rand.New(rand.NewSource(int64(seed)))
+		data := randomMeasurements(r, size.lines, size.stations)
+		path := filepath.Join(t.TempDir(), "measurements.txt")
+		if err := os.WriteFile(path, data, 0666); err != nil {
+			t.Fatal(err)
+		}
+		want := naiveStats(t, data)
+
+		t.Run(fmt.Sprintf("%d lines", size.lines), func(t *testing.T) {
+			solveAll(t, path, func(t *testing.T, result Result) {
+				if len(result.Stations) != len(want) {
+					t.Fatalf("got %d stations, want %d", len(result.Stations), len(want))
+				}
+				for i, got := range result.Stations {
+					w := want[i]
+					if got.Station != w.Station || got.Min != w.Min || got.Max != w.Max || got.Sum != w.Sum || got.Count != w.Count {
+						t.Fatalf("got %q %d/%d/%d count %d, want %q %d/%d/%d count %d",
+							got.Station, got.Min, got.Sum, got.Max, got.Count, w.Station, w.Min, w.Sum, w.Max, w.Count)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package brc
+
+import "bytes"
+
+const defaultTableBuckets = 1 << 17 // initial number of buckets of a stationTable (power of 2)
+
+// stationTable is an open-addressing hash table with linear probing that
+// maps station names to their stats of type V. The names are copied back to
+// back into a single arena instead of one allocation per station, and the
+// table doubles its buckets once it is half full.
+type stationTable[V any] struct {
+	slots []tableSlot[V]
+	arena []byte
+	size  int
+}
+
+type tableSlot[V any] struct {
+	hash      uint64
+	keyOffset uint32 // of the name in the arena
+	keyLength uint32 // 0 for an empty slot, names are never empty
+	stats     V
+}
+
+// newStationTable returns an empty table with buckets slots, a power of 2.
+func newStationTable[V any](buckets int) *stationTable[V] {
+	return &stationTable[V]{slots: make([]tableSlot[V], buckets)}
+}
+
+func (t *stationTable[V]) key(slot *tableSlot[V]) []byte {
+	return t.arena[slot.keyOffset : slot.keyOffset+slot.keyLength]
+}
+
+// get returns the stats of station, hash being its hash, adding zero stats
+// when it is new. The pointer is only valid until the next call to get.
+func (t *stationTable[V]) get(hash uint64, station []byte) (stats *V, added bool) {
+	mask := uint64(len(t.slots) - 1)
+	for i := hash & mask; ; i = (i + 1) & mask {
+		slot := &t.slots[i]
+		if slot.keyLength == 0 {
+			if 2*(t.size+1) > len(t.slots) {
+				t.grow()
+				return t.get(hash, station)
+			}
+			slot.hash = hash
+			slot.keyOffset = uint32(len(t.arena))
+			slot.keyLength = uint32(len(station))
+			t.arena = append(t.arena, station...)
+			t.size++
+			return &slot.stats, true
+		}
+		if slot.hash == hash && bytes.Equal(t.key(slot), station) {
+			return &slot.stats, false
+		}
+	}
+}
+
+// contains reports whether station, hash being its hash, is in the table.
+func (t *stationTable[V]) contains(hash uint64, station []byte) bool {
+	mask := uint64(len(t.slots) - 1)
+	for i := hash & mask; ; i = (i + 1) & mask {
+		slot := &t.slots[i]
+		if slot.keyLength == 0 {
+			return false
+		}
+		if slot.hash == hash && bytes.Equal(t.key(slot), station) {
+			return true
+		}
+	}
+}
+
+// grow doubles the number of slots, placing the entries again by their
+// stored hash.
+func (t *stationTable[V]) grow() {
+	slots := t.slots
+	t.slots = make([]tableSlot[V], 2*len(slots))
+	mask := uint64(len(t.slots) - 1)
+	for _, slot := range slots {
+		if slot.keyLength == 0 {
+			continue
+		}
+		i := slot.hash & mask
+		for t.slots[i].keyLength != 0 {
+			i = (i + 1) & mask
+		}
+		t.slots[i] = slot
+	}
+}
+
+// each calls fn with every station and its stats, in no particular order.
+func (t *stationTable[V]) each(fn func(station []byte, stats *V)) {
+	for i := range t.slots {
+		if slot := &t.slots[i]; slot.keyLength != 0 {
+			fn(t.key(slot), &slot.stats)
+		}
+	}
+}
+
+// toMap returns the stats by station name.
+func (t *stationTable[V]) toMap() map[string]*V {
+	stats := make(map[string]*V, t.size)
+	t.each(func(station []byte, stat *V) {
+		stats[string(station)] = stat
+	})
+	return stats
+}
+
+// merge adds the stations of other to t by their stored hash, without
+// hashing the names again, calling combine for the stations in both tables.
+func (t *stationTable[V]) merge(other *stationTable[V], combine func(dst, src *V)) {
+	for i := range other.slots {
+		src := &other.slots[i]
+		if src.keyLength == 0 {
+			continue
+		}
+		dst, added := t.get(src.hash, other.key(src))
+		if added {
+			*dst = src.stats
+			continue
+		}
+		combine(dst, &src.stats)
+	}
+}
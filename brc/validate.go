@@ -0,0 +1,163 @@
+package brc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const maxStationLength = 100 // in bytes
+
+// MalformedLineError reports the first malformed line found with PolicyStrict.
+type MalformedLineError struct {
+	Offset int64 // of the line in the (decompressed) input
+	Line   int64 // 1-based line number, 0 if unknown
+	Text   string
+	Reason string
+}
+
+func (e *MalformedLineError) Error() string {
+	return fmt.Sprintf("malformed line %d at offset %d: %s: %q", e.Line, e.Offset, e.Reason, e.Text)
+}
+
+// temperatureLength returns the length of the "-?d?d.d" temperature at the
+// start of b, the only shape the parsers accept, or 0 if it is malformed.
+func temperatureLength(b []byte) int {
+	i := 0
+	if len(b) > 0 && b[0] == '-' {
+		i++
+	}
+	digits := i
+	for i < len(b) && i-digits < 3 && b[i] >= '0' && b[i] <= '9' {
+		i++
+	}
+	if n := i - digits; n < 1 || n > 2 {
+		return 0
+	}
+	if i+1 >= len(b) || b[i] != '.' || b[i+1] < '0' || b[i+1] > '9' {
+		return 0
+	}
+	return i + 2
+}
+
+// malformedReason validates a line without its newline and returns why it
+// is malformed, or "" if it is valid.
+func malformedReason(line []byte) string {
+	semicolon := bytes.IndexByte(line, ';')
+	if semicolon < 0 {
+		return "missing ';'"
+	}
+	if semicolon == 0 || semicolon > maxStationLength {
+		return "invalid station name"
+	}
+	if temp := line[semicolon+1:]; len(temp) == 0 || temperatureLength(temp) != len(temp) {
+		return "invalid temperature"
+	}
+	return ""
+}
+
+// malformedFields validates a line split at its first ';' or newline, sep
+// being the byte it was split at and temp starting after it, and returns
+// why the line is malformed, or "" if it is valid.
+func malformedFields(sep byte, station, temp []byte) string {
+	if sep != ';' {
+		return "missing ';'"
+	}
+	if len(station) == 0 || len(station) > maxStationLength {
+		return "invalid station name"
+	}
+	if n := temperatureLength(temp); n == 0 || n == len(temp) || temp[n] != '\n' {
+		return "invalid temperature"
+	}
+	return ""
+}
+
+// lineRejecter applies the malformed line policy of an Input. It is shared
+// by all workers of a run.
+type lineRejecter struct {
+	policy Policy
+	mu     sync.Mutex // guards report
+	report io.Writer
+	count  atomic.Int64
+}
+
+func newLineRejecter(input Input) (*lineRejecter, error) {
+	switch input.Policy {
+	case "":
+		return &lineRejecter{policy: PolicyStrict}, nil
+	case PolicyStrict, PolicySkip:
+		return &lineRejecter{policy: input.Policy}, nil
+	case PolicyReport:
+		if input.Rejects == nil {
+			return nil, errors.New("policy report needs a writer for the rejected lines")
+		}
+		return &lineRejecter{policy: input.Policy, report: input.Rejects}, nil
+	default:
+		return nil, fmt.Errorf("invalid policy %q, should be %s, %s or %s", input.Policy, PolicyStrict, PolicySkip, PolicyReport)
+	}
+}
+
+// reject handles the malformed line, given without its newline, and fails
+// with a *MalformedLineError with PolicyStrict.
+func (r *lineRejecter) reject(line []byte, offset, lineNumber int64, reason string) error {
+	switch r.policy {
+	case PolicyStrict:
+		return &MalformedLineError{Offset: offset, Line: lineNumber, Text: string(line), Reason: reason}
+	case PolicyReport:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, err := fmt.Fprintf(r.report, "%s\n", line); err != nil {
+			return err
+		}
+	}
+	r.count.Add(1)
+	return nil
+}
+
+// rejectLine rejects the line at the start of rest, a suffix of b.data,
+// and returns the lines following it.
+func (r *lineRejecter) rejectLine(b block, rest []byte, reason string) ([]byte, error) {
+	end := bytes.IndexByte(rest, '\n') // blocks always end with a newline
+	pos := len(b.data) - len(rest)
+	if err := r.reject(rest[:end], b.offset+int64(pos), b.lineAt(pos), reason); err != nil {
+		return nil, err
+	}
+	return rest[end+1:], nil
+}
+
+// rejected returns the number of lines dropped so far.
+func (r *lineRejecter) rejected() int64 {
+	return r.count.Load()
+}
+
+// locateMalformedLine fills in the line number of a *MalformedLineError
+// found in a file chunk, whose first line number is unknown to the worker,
+// by counting the newlines before it.
+func locateMalformedLine(input Input, err error) error {
+	var malformed *MalformedLineError
+	if !errors.As(err, &malformed) || malformed.Line != 0 || input.Reader != nil {
+		return err
+	}
+
+	reader, openErr := openInput(input)
+	if openErr != nil {
+		return err
+	}
+	defer reader.Close()
+
+	buf := make([]byte, blockSize)
+	lines := int64(1)
+	for remaining := malformed.Offset; remaining > 0; {
+		n, readErr := reader.Read(buf[:min(int64(len(buf)), remaining)])
+		lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		remaining -= int64(n)
+		if readErr != nil {
+			return err
+		}
+	}
+	malformed.Line = lines
+	return err
+}
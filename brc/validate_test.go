@@ -0,0 +1,116 @@
+package brc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var malformedLines = []struct {
+	name, line, reason string
+}{
+	{"empty temperature", "Hamburg;", "invalid temperature"},
+	{"no fractional digit", "Hamburg;1", "invalid temperature"},
+	{"missing semicolon", "Hamburg12.3", "missing ';'"},
+	{"empty name", ";12.3", "invalid station name"},
+	{"101-byte name", strings.Repeat("a", 101) + ";12.3", "invalid station name"},
+	{"two fractional digits", "Hamburg;1.23", "invalid temperature"},
+	{"three integer digits", "Hamburg;100.0", "invalid temperature"},
+	{"no integer digit", "Hamburg;.5", "invalid temperature"},
+	{"CRLF line ending", "Hamburg;12.3\r", "invalid temperature"},
+	{"line longer than 64KB", strings.Repeat("x", 100_000) + ";12.3", "invalid station name"},
+	{"line longer than a block", strings.Repeat("x", 2*blockSize), "missing ';'"},
+}
+
+func TestMalformedReason(t *testing.T) {
+	for _, tt := range malformedLines {
+		t.Run(tt.name, func(t *testing.T) {
+			line := []byte(tt.line)
+			if got := malformedReason(line); got != tt.reason {
+				t.Errorf("malformedReason(%q) = %q, want %q", tt.line, got, tt.reason)
+			}
+
+			// the parsers split the line at its first ';' or newline
+			withNewline := append(line, '\n')
+			i := bytes.IndexAny(withNewline, ";\n")
+			if got := malformedFields(withNewline[i], withNewline[:i], withNewline[i+1:]); got != tt.reason {
+				t.Errorf("malformedFields(%q) = %q, want %q", tt.line, got, tt.reason)
+			}
+		})
+	}
+
+	for _, line := range []string{"Hamburg;12.3", "a;-99.9", "b;0.0", "c;-1.5", strings.Repeat("é", 50) + ";99.9"} {
+		if got := malformedReason([]byte(line)); got != "" {
+			t.Errorf("malformedReason(%q) = %q, want valid", line, got)
+		}
+	}
+}
+
+// policyInputs are the ways to read a file a solver should handle the same.
+var policyInputs = []struct {
+	name   string
+	io     IOMode
+	reader bool
+}{
+	{"buffered", IOBuffered, false},
+	{"mmap", IOMmap, false},
+	{"reader", IOBuffered, true},
+}
+
+func TestPolicies(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range malformedLines {
+		data := "Oslo;1.0\n" + tt.line + "\nOslo;2.0\n"
+		path := filepath.Join(t.TempDir(), "measurements.txt")
+		if err := os.WriteFile(path, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, solver := range Solvers() {
+			for _, in := range policyInputs {
+				t.Run(tt.name+"/"+solver.Name()+"/"+in.name, func(t *testing.T) {
+					input := func(policy Policy) Input {
+						input := Input{FilePath: path, IO: in.io, Policy: policy, Workers: 3, SegmentSize: 4}
+						if in.reader {
+							input.FilePath, input.Reader = "", strings.NewReader(data)
+						}
+						return input
+					}
+
+					_, err := solver.Solve(ctx, input(PolicyStrict))
+					var malformed *MalformedLineError
+					if !errors.As(err, &malformed) {
+						t.Fatalf("strict: got error %v, want a *MalformedLineError", err)
+					}
+					if malformed.Line != 2 || malformed.Offset != 9 || malformed.Text != tt.line {
+						t.Errorf("strict: got line %d at offset %d %q, want line 2 at offset 9 %q",
+							malformed.Line, malformed.Offset, malformed.Text, tt.line)
+					}
+
+					result, err := solver.Solve(ctx, input(PolicySkip))
+					if err != nil {
+						t.Fatalf("skip: %v", err)
+					}
+					if result.Rejected != 1 || len(result.Stations) != 1 || result.Stations[0].Count != 2 {
+						t.Errorf("skip: got %d rejected and stations %v, want 1 rejected and Oslo twice", result.Rejected, result.Stations)
+					}
+
+					var rejects bytes.Buffer
+					reportInput := input(PolicyReport)
+					reportInput.Rejects = &rejects
+					result, err = solver.Solve(ctx, reportInput)
+					if err != nil {
+						t.Fatalf("report: %v", err)
+					}
+					if result.Rejected != 1 || rejects.String() != tt.line+"\n" {
+						t.Errorf("report: got %d rejected and %q, want 1 and %q", result.Rejected, rejects.String(), tt.line+"\n")
+					}
+				})
+			}
+		}
+	}
+}
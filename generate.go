@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"unicode/utf8"
+)
+
+const (
+	maxStations      = 10_000
+	maxStationLength = 100 // in bytes
+	maxTemperature   = 999 // in tenths of a degree
+)
+
+// nameRunes are the characters station names are built from; the non-ASCII
+// ones make sure parsers handle multi-byte UTF-8 names.
+var nameRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ éüøłçñåßžąőœ京都東北ñ")
+
+type generateOptions struct {
+	rows       int64
+	stations   int
+	seed       int64
+	nameLength string
+	workers    int
+}
+
+type station struct {
+	name []byte
+	mean float64
+}
+
+// stationNameLength picks the byte length of a station name for the given distribution.
+func stationNameLength(rng *rand.Rand, distribution string) (int, error) {
+	switch distribution {
+	case "short":
+		return 3 + rng.Intn(14), nil // 3..16 bytes
+	case "mixed":
+		return 1 + rng.Intn(maxStationLength), nil
+	case "long":
+		return maxStationLength, nil
+	default:
+		return 0, fmt.Errorf("invalid name length distribution %q, should be short, mixed or long", distribution)
+	}
+}
+
+// stationName builds a random UTF-8 name of exactly length bytes.
+func stationName(rng *rand.Rand, length int) []byte {
+	name := make([]byte, 0, length)
+	for len(name) < length {
+		r := nameRunes[rng.Intn(len(nameRunes))]
+		if len(name)+utf8.RuneLen(r) > length {
+			// not enough room for a multi-byte rune, pad with ASCII
+			r = rune('a' + rng.Intn(26))
+		}
+		name = utf8.AppendRune(name, r)
+	}
+	return name
+}
+
+func generateStations(opts generateOptions) ([]station, error) {
+	rng := rand.New(rand.NewSource(opts.seed))
+	seen := make(map[string]bool, opts.stations)
+	stations := make([]station, 0, opts.stations)
+
+	for attempts := 0; len(stations) < opts.stations; attempts++ {
+		if attempts > 1000*opts.stations {
+			return nil, fmt.Errorf("could not generate %d unique station names", opts.stations)
+		}
+
+		length, err := stationNameLength(rng, opts.nameLength)
+		if err != nil {
+			return nil, err
+		}
+		name := stationName(rng, length)
+		if seen[string(name)] {
+			continue
+		}
+		seen[string(name)] = true
+		stations = append(stations, station{name: name, mean: rng.Float64()*80 - 30})
+	}
+	return stations, nil
+}
+
+// appendTemperature appends a temperature given in tenths of a degree
+// with exactly one fractional digit, e.g. -12.3
+func appendTemperature(buf []byte, temp int) []byte {
+	if temp < 0 {
+		buf = append(buf, '-')
+		temp = -temp
+	}
+	if temp >= 100 {
+		buf = append(buf, byte('0'+temp/100))
+	}
+	buf = append(buf, byte('0'+temp/10%10), '.', byte('0'+temp%10))
+	return buf
+}
+
+type generatedBlock struct {
+	index int64
+	data  []byte
+}
+
+// generateRows writes rows of "station;temp" to output. Rows are produced in
+// fixed size blocks by parallel workers, each block with its own seed, and
+// written in order so the output only depends on the options.
+func generateRows(opts generateOptions, stations []station, output io.Writer) error {
+	const blockRows = 1 << 16
+
+	blocks := (opts.rows + blockRows - 1) / blockRows
+
+	tokens := make(chan struct{}, 2*opts.workers) // bounds the blocks held in memory
+	jobs := make(chan int64)
+	results := make(chan generatedBlock, opts.workers)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(jobs)
+		for i := int64(0); i < blocks; i++ {
+			select {
+			case tokens <- struct{}{}:
+			case <-done:
+				return
+			}
+			jobs <- i
+		}
+	}()
+
+	for w := 0; w < opts.workers; w++ {
+		go func() {
+			for i := range jobs {
+				rng := rand.New(rand.NewSource(opts.seed + i + 1))
+				rows := min(int64(blockRows), opts.rows-i*blockRows)
+				buf := make([]byte, 0, rows*16)
+				for r := int64(0); r < rows; r++ {
+					s := stations[rng.Intn(len(stations))]
+					temp := int(s.mean*10 + rng.NormFloat64()*100)
+					temp = max(-maxTemperature, min(maxTemperature, temp))
+
+					buf = append(buf, s.name...)
+					buf = append(buf, ';')
+					buf = appendTemperature(buf, temp)
+					buf = append(buf, '\n')
+				}
+				select {
+				case results <- generatedBlock{index: i, data: buf}:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	pending := make(map[int64][]byte)
+	for next := int64(0); next < blocks; {
+		block := <-results
+		pending[block.index] = block.data
+		for data, ok := pending[next]; ok; data, ok = pending[next] {
+			if _, err := output.Write(data); err != nil {
+				return err
+			}
+			delete(pending, next)
+			next++
+			<-tokens
+		}
+	}
+	return nil
+}
+
+func generate(args []string) error {
+	var outPath string
+	var opts generateOptions
+
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	flags.StringVar(&outPath, "out", "", "Path to write the measurements file to ('-' for stdout)")
+	flags.Int64Var(&opts.rows, "rows", 1_000_000_000, "Number of rows to generate")
+	flags.IntVar(&opts.stations, "stations", 413, fmt.Sprintf("Number of distinct weather stations (1 to %d)", maxStations))
+	flags.Int64Var(&opts.seed, "seed", 1, "Seed for the random generator, the same seed produces the same file")
+	flags.StringVar(&opts.nameLength, "name_length", "short", "Station name length distribution: short (3-16 bytes), mixed (1-100 bytes) or long (100 bytes)")
+	flags.IntVar(&opts.workers, "workers", runtime.NumCPU(), "Number of goroutines generating rows")
+	flags.Parse(args)
+
+	switch {
+	case outPath == "":
+		fmt.Fprintln(os.Stderr, "Error: Required flag '-out' is missing")
+		flags.Usage()
+		os.Exit(1)
+	case opts.rows < 0:
+		return fmt.Errorf("invalid rows %d, should not be negative", opts.rows)
+	case opts.stations < 1 || opts.stations > maxStations:
+		return fmt.Errorf("invalid stations %d, should be between 1 and %d", opts.stations, maxStations)
+	case opts.workers < 1:
+		return fmt.Errorf("invalid workers %d, should be at least 1", opts.workers)
+	}
+
+	stations, err := generateStations(opts)
+	if err != nil {
+		return err
+	}
+
+	output := os.Stdout
+	if outPath != "-" {
+		output, err = os.Create(outPath)
+		if err != nil {
+			return err
+		}
+		defer output.Close()
+	}
+
+	writer := bufio.NewWriterSize(output, 1024*1024)
+	if err := generateRows(opts, stations, writer); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
@@ -1,3 +1,5 @@
 module 1brc-go
 
 go 1.21
+
+require github.com/klauspost/compress v1.17.11
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// environment records where a benchmark ran, to tell apart runs whose
+// timings are not comparable.
+func (report *benchmarkReport) environment() {
+	report.Commit = gitCommit()
+	report.GoVersion = runtime.Version()
+	report.GOMAXPROCS = runtime.GOMAXPROCS(0)
+	report.CPU = cpuModel()
+}
+
+// gitCommit returns the commit the binary was built from, or the one
+// checked out in the working directory for `go run`, "" if unknown.
+func gitCommit() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		var revision, modified string
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.modified":
+				modified = setting.Value
+			}
+		}
+		if revision != "" && modified == "true" {
+			return revision + "-dirty"
+		}
+		if revision != "" {
+			return revision
+		}
+	}
+
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// cpuModel returns the CPU model name from /proc/cpuinfo, "" if unknown.
+func cpuModel() string {
+	data, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(name) == "model name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// appendHistory appends report as a line of JSON to the history file.
+func appendHistory(historyPath string, report benchmarkReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(historyPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// loadHistory reads all the runs of a history file, oldest first.
+func loadHistory(historyPath string) ([]benchmarkReport, error) {
+	data, err := os.ReadFile(historyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []benchmarkReport
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var run benchmarkReport
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", historyPath, line, err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, scanner.Err()
+}
+
+// findRun returns the run selected by ref, an index in runs (negative ones
+// counting from the end) or a prefix of the commit of the latest matching run.
+// A ref made of digits out of the range of the indexes is a commit prefix.
+func findRun(runs []benchmarkReport, ref string) (benchmarkReport, error) {
+	index, err := strconv.Atoi(ref)
+	isIndex := err == nil
+	if isIndex {
+		if index < 0 {
+			index += len(runs)
+		}
+		if index >= 0 && index < len(runs) {
+			return runs[index], nil
+		}
+	}
+
+	for i := len(runs) - 1; i >= 0; i-- {
+		if ref != "" && strings.HasPrefix(runs[i].Commit, ref) {
+			return runs[i], nil
+		}
+	}
+	if isIndex {
+		return benchmarkReport{}, fmt.Errorf("run %s out of range, the history has %d runs, and no run of commit %q", ref, len(runs), ref)
+	}
+	return benchmarkReport{}, fmt.Errorf("no run of commit %q in the history", ref)
+}
+
+// mannWhitney returns the two-sided p-value of the Mann-Whitney U test that
+// the timings of a and b come from the same distribution. It is exact for
+// small samples without ties and uses the normal approximation with tie and
+// continuity corrections otherwise.
+func mannWhitney(a, b []float64) float64 {
+	type sample struct {
+		value float64
+		fromA bool
+	}
+	samples := make([]sample, 0, len(a)+len(b))
+	for _, v := range a {
+		samples = append(samples, sample{v, true})
+	}
+	for _, v := range b {
+		samples = append(samples, sample{v, false})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].value < samples[j].value })
+
+	// Rank the samples, ties getting the mean of their ranks
+	var rankSumA, ties float64
+	for i := 0; i < len(samples); {
+		j := i
+		for j < len(samples) && samples[j].value == samples[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if samples[k].fromA {
+				rankSumA += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	n1, n2 := float64(len(a)), float64(len(b))
+	n := n1 + n2
+	u := rankSumA - n1*(n1+1)/2
+	if ties == 0 && len(a)*len(b) <= exactMaxProduct {
+		return exactMannWhitney(int(u), len(a), len(b))
+	}
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * (n + 1 - ties/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	return math.Erfc(max(z, 0) / math.Sqrt2)
+}
+
+// exactMaxProduct bounds the sizes of the samples whose U distribution is
+// computed exactly, the normal approximation being good enough above.
+const exactMaxProduct = 400
+
+// uCounts returns how many orderings of n1 and n2 samples give each U from
+// 0 to n1*n2, using f(n1, n2, u) = f(n1-1, n2, u-n2) + f(n1, n2-1, u).
+func uCounts(n1, n2 int) []float64 {
+	// counts[j] holds f(i, j, .) for the current i
+	counts := make([][]float64, n2+1)
+	for j := range counts {
+		counts[j] = []float64{1} // f(0, j, 0) = 1
+	}
+	for i := 1; i <= n1; i++ {
+		next := make([][]float64, n2+1)
+		next[0] = []float64{1} // f(i, 0, 0) = 1
+		for j := 1; j <= n2; j++ {
+			next[j] = make([]float64, i*j+1)
+			for u := range next[j] {
+				if u-j >= 0 && u-j < len(counts[j]) {
+					next[j][u] += counts[j][u-j]
+				}
+				if u < len(next[j-1]) {
+					next[j][u] += next[j-1][u]
+				}
+			}
+		}
+		counts = next
+	}
+	return counts[n2]
+}
+
+// exactMannWhitney returns the exact two-sided p-value of u for samples of
+// n1 and n2 values without ties.
+func exactMannWhitney(u, n1, n2 int) float64 {
+	counts := uCounts(n1, n2)
+	var total, below, above float64
+	for v, count := range counts {
+		total += count
+		if v <= u {
+			below += count
+		}
+		if v >= u {
+			above += count
+		}
+	}
+	return min(1, 2*min(below, above)/total)
+}
+
+// minPValue returns the smallest two-sided p-value the test can give for
+// samples of n1 and n2 values, when all of one are below all of the other.
+func minPValue(n1, n2 int) float64 {
+	if n1*n2 <= exactMaxProduct {
+		return exactMannWhitney(0, n1, n2)
+	}
+	return 0
+}
+
+// compare compares two runs of a history file and fails when a solution
+// is significantly slower in the second one by more than the threshold.
+func compare(args []string) error {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	var historyPath, baseRef, headRef string
+	var threshold, alpha float64
+	flags.StringVar(&historyPath, "history", "", "Path of the benchmark history written by -history")
+	flags.StringVar(&baseRef, "base", "-2", "Run to compare against: index in the history (negative from the end) or commit prefix")
+	flags.StringVar(&headRef, "head", "-1", "Run to compare: index in the history (negative from the end) or commit prefix")
+	flags.Float64Var(&threshold, "threshold", 5, "Median slowdown in percent above which a significant change is a regression")
+	flags.Float64Var(&alpha, "alpha", 0.05, "Significance level of the Mann-Whitney U test")
+	flags.Parse(args)
+
+	if historyPath == "" {
+		return errors.New("required flag '-history' is missing")
+	}
+
+	runs, err := loadHistory(historyPath)
+	if err != nil {
+		return err
+	}
+	base, err := findRun(runs, baseRef)
+	if err != nil {
+		return err
+	}
+	head, err := findRun(runs, headRef)
+	if err != nil {
+		return err
+	}
+
+	describe := func(run benchmarkReport) string {
+		return fmt.Sprintf("%s (%s, %s, GOMAXPROCS=%d, %s, %dMB)",
+			run.Time.Format("2006-01-02 15:04"), shortCommit(run.Commit), run.GoVersion, run.GOMAXPROCS, run.CPU, run.FileSize>>20)
+	}
+	fmt.Printf("base: %s\nhead: %s\n", describe(base), describe(head))
+	if base.File != head.File || base.FileSize != head.FileSize {
+		fmt.Println("warning: the runs measured different files")
+	}
+
+	baseSolutions := make(map[string]solutionReport, len(base.Solutions))
+	for _, solution := range base.Solutions {
+		baseSolutions[solution.Name] = solution
+	}
+
+	var regressed, untestable []string
+	for _, h := range head.Solutions {
+		b, ok := baseSolutions[h.Name]
+		switch {
+		case !ok:
+			fmt.Printf("%s: not in base\n", h.Name)
+			continue
+		case h.Failed != "" || b.Failed != "":
+			fmt.Printf("%s: failed verification, base %q, head %q\n", h.Name, b.Failed, h.Failed)
+			continue
+		}
+
+		if p := minPValue(len(b.Timings), len(h.Timings)); p >= alpha {
+			fmt.Printf("%s: %d and %d timings can't be significant at alpha %g (p >= %.3f), benchmark with more -runs\n",
+				h.Name, len(b.Timings), len(h.Timings), alpha, p)
+			untestable = append(untestable, h.Name)
+			continue
+		}
+
+		delta := 100 * (float64(h.Median) - float64(b.Median)) / float64(b.Median)
+		p := mannWhitney(seconds(b), seconds(h))
+		verdict := "no significant change"
+		switch {
+		case p < alpha && delta > threshold:
+			verdict = "REGRESSION"
+			regressed = append(regressed, h.Name)
+		case p < alpha && delta > 0:
+			verdict = "slower"
+		case p < alpha:
+			verdict = "faster"
+		}
+		fmt.Printf("%s: median %v -> %v (%+.1f%%), p=%.3f - %s\n", h.Name, b.Median.Round(time.Microsecond), h.Median.Round(time.Microsecond), delta, p, verdict)
+	}
+
+	if len(regressed) > 0 {
+		return fmt.Errorf("%s regressed by more than %g%%", strings.Join(regressed, ", "), threshold)
+	}
+	if len(untestable) > 0 {
+		return fmt.Errorf("too few timings to detect a regression of %s", strings.Join(untestable, ", "))
+	}
+	return nil
+}
+
+func seconds(solution solutionReport) []float64 {
+	values := make([]float64, len(solution.Timings))
+	for i, t := range solution.Timings {
+		values[i] = t.Seconds()
+	}
+	return values
+}
+
+func shortCommit(commit string) string {
+	if commit == "" {
+		return "unknown commit"
+	}
+	return commit[:min(len(commit), 12)]
+}
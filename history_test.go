@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMannWhitney(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		// all of a below all of b: 2 of the C(n1+n2, n1) orderings are as extreme
+		{"3 vs 3 apart", []float64{1, 2, 3}, []float64{4, 5, 6}, 2.0 / 20},
+		{"4 vs 4 apart", []float64{1, 2, 3, 4}, []float64{5, 6, 7, 8}, 2.0 / 70},
+		{"5 vs 5 apart", []float64{6, 7, 8, 9, 10}, []float64{1, 2, 3, 4, 5}, 2.0 / 252},
+		// U = 1: orderings with U <= 1 are 2 of 20
+		{"3 vs 3 one swap", []float64{1, 2, 4}, []float64{3, 5, 6}, 4.0 / 20},
+		// U = 3: counts of U from 0 to 9 are 1 1 2 3 3 3 3 2 1 1, 7 of 20 up to 3
+		{"interleaved", []float64{1, 3, 5}, []float64{2, 4, 6}, 14.0 / 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mannWhitney(tt.a, tt.b); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("mannWhitney(%v, %v) = %g, want %g", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUCounts(t *testing.T) {
+	// the counts sum to the number of orderings C(n1+n2, n1)
+	for n1 := 1; n1 <= 8; n1++ {
+		for n2 := 1; n2 <= 8; n2++ {
+			var total float64
+			for _, count := range uCounts(n1, n2) {
+				total += count
+			}
+			want := math.Round(math.Exp(lgamma(n1+n2+1) - lgamma(n1+1) - lgamma(n2+1)))
+			if total != want {
+				t.Errorf("uCounts(%d, %d) sums to %g, want %g", n1, n2, total, want)
+			}
+		}
+	}
+}
+
+func lgamma(n int) float64 {
+	v, _ := math.Lgamma(float64(n))
+	return v
+}
+
+func TestMinPValue(t *testing.T) {
+	if p := minPValue(3, 3); p < 0.05 {
+		t.Errorf("minPValue(3, 3) = %g, 3 timings should not reach 0.05", p)
+	}
+	if p := minPValue(4, 4); p >= 0.05 {
+		t.Errorf("minPValue(4, 4) = %g, 4 timings should reach 0.05", p)
+	}
+}
+
+func TestFindRun(t *testing.T) {
+	runs := []benchmarkReport{{Commit: "1234567abc"}, {Commit: "abcdef0123"}, {Commit: "1234567def"}, {Commit: "0987654321"}}
+	tests := []struct {
+		ref  string
+		want string // commit of the run, "" for an error
+	}{
+		{"0", "1234567abc"},
+		{"3", "0987654321"},
+		{"-1", "0987654321"},
+		{"-4", "1234567abc"},
+		{"abcdef", "abcdef0123"},
+		{"1234567", "1234567def"}, // digits out of range, the latest matching commit
+		{"0987", "0987654321"},
+		{"4", ""},
+		{"-5", ""},
+		{"fedcba", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		run, err := findRun(runs, tt.ref)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("findRun(%q) = %s, want an error", tt.ref, run.Commit)
+		case tt.want != "" && err != nil:
+			t.Errorf("findRun(%q): %v", tt.ref, err)
+		case run.Commit != tt.want:
+			t.Errorf("findRun(%q) = %s, want %s", tt.ref, run.Commit, tt.want)
+		}
+	}
+}
@@ -1,67 +1,158 @@
 package main
 
 import (
-	"bytes"
+	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"math"
 	"os"
-	"runtime/pprof"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
-)
-
-type solutionFunc func(string, io.Writer) error
 
-var solutions = []solutionFunc{solution1, solution2, solution3, solution4, solution5}
+	"1brc-go/brc"
+)
 
-func benchmark(filePath string) error {
-	const MaxTries = 5
+// writeResult writes the output of encode to outPath, '-' being stdout.
+func writeResult(outPath string, encode func(output io.Writer) error) error {
+	if outPath == "-" {
+		return encode(os.Stdout)
+	}
 
-	var output1 bytes.Buffer // use buffer as output not to clutter the stdout
-	err := solution1(filePath, &output1)
+	output, err := os.Create(outPath)
 	if err != nil {
 		return err
 	}
+	defer output.Close()
 
-	var s1Best time.Duration
+	writer := bufio.NewWriter(output)
+	if err := encode(writer); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return output.Close()
+}
 
-	for i, solution := range solutions {
-		fmt.Printf("solution%d: ", i+1)
-		bestTime := time.Duration(math.MaxInt64)
+// openRejects opens the writer for the malformed lines reported by
+// -policy=report, '-' being stderr.
+func openRejects(rejectsPath string) (io.Writer, func() error, error) {
+	if rejectsPath == "-" {
+		return os.Stderr, func() error { return nil }, nil
+	}
 
-		for trial := 0; trial < MaxTries; trial++ {
-			var output2 bytes.Buffer
-			start := time.Now()
-			err := solution(filePath, &output2)
-			if err != nil {
-				return err
-			}
-			elapsed := time.Since(start)
-			fmt.Fprintf(os.Stdout, " %v", elapsed)
-			bestTime = min(bestTime, elapsed)
-			if i == 0 {
-				s1Best = bestTime
-			}
+	file, err := os.Create(rejectsPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	writer := bufio.NewWriter(file)
+	closeRejects := func() error {
+		if err := writer.Flush(); err != nil {
+			file.Close()
+			return err
 		}
+		return file.Close()
+	}
+	return writer, closeRejects, nil
+}
 
-		fmt.Fprintf(os.Stdout, " - best: %v (%.2fx faster than solution1)\n",
-			bestTime, float64(s1Best)/float64(bestTime))
+// parseRange parses the bounds in degrees of a temperature range, nil when
+// both are empty, a missing one leaving that side unbounded.
+func parseRange(low, high string) (*brc.TemperatureRange, error) {
+	if low == "" && high == "" {
+		return nil, nil
 	}
-	return nil
+	bounds := brc.TemperatureRange{Min: math.MinInt64, Max: math.MaxInt64}
+	for _, bound := range []struct {
+		value  string
+		tenths *int64
+	}{{low, &bounds.Min}, {high, &bounds.Max}} {
+		if bound.value == "" {
+			continue
+		}
+		degrees, err := strconv.ParseFloat(bound.value, 64)
+		if err != nil {
+			return nil, err
+		}
+		*bound.tenths = int64(math.Round(degrees * 10))
+	}
+	if bounds.Min > bounds.Max {
+		return nil, fmt.Errorf("%s is above %s", low, high)
+	}
+	return &bounds, nil
 }
 
 func main() {
 	var filePath string
-	var cpuProfilePath string
+	var profiles profileOptions
 	var solution int
+	var ioMode string
+	var outPath string
+	var format string
+	var policy string
+	var rejectsPath string
+	var workers int
+	var segmentSize int64
+	var statsList string
+	var minTemp, maxTemp, outlierBelow, outlierAbove string
+	var bucketWidth int64
+	var stationNames, stationPrefixes, stationRegex string
+	var options benchmarkOptions
+	var reportPath string
+	var historyPath string
 
 	var err error
 
-	flag.StringVar(&filePath, "file", "", "Path to the weather station data file")
-	flag.StringVar(&cpuProfilePath, "cpu_profile", "", "Path to save CPU profile to")
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		if err = generate(os.Args[2:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		if err = compare(os.Args[2:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	flag.StringVar(&filePath, "file", "", "Path to the weather station data file ('-' for stdin)")
+	flag.StringVar(&profiles.cpu, "cpu_profile", "", "Path to save CPU profile to")
+	flag.StringVar(&profiles.mem, "mem_profile", "", "Path to save the memory allocations profile to")
+	flag.StringVar(&profiles.block, "block_profile", "", "Path to save the goroutine blocking profile to")
+	flag.StringVar(&profiles.mutex, "mutex_profile", "", "Path to save the mutex contention profile to")
+	flag.StringVar(&profiles.trace, "trace", "", "Path to save the execution trace to")
 	flag.IntVar(&solution, "solution", 0, "Solution to run")
+	flag.StringVar(&ioMode, "io", string(brc.IOBuffered), "How the parallel solutions read the file: buffered or mmap")
+	flag.StringVar(&outPath, "out", "", "Path to write the result of -solution to ('-' for stdout)")
+	flag.StringVar(&format, "format", "brace", fmt.Sprintf("Output format of -out: %s", strings.Join(brc.EncoderFormats(), ", ")))
+	flag.StringVar(&policy, "policy", string(brc.PolicyStrict), "What to do with malformed lines: strict (fail), skip or report")
+	flag.StringVar(&rejectsPath, "rejects", "", "Path to write the malformed lines to with -policy=report ('-' for stderr)")
+	flag.IntVar(&workers, "workers", runtime.NumCPU(), "Number of goroutines of the parallel solutions")
+	flag.Int64Var(&segmentSize, "segment_size", 8*1024*1024, "Size in bytes of the file segments the workers of the parallel solutions take one at a time")
+	flag.StringVar(&statsList, "stats", "", "Extra statistics of solution5 and solution6, comma separated: percentiles (median, p90, p99 and mode, 8KB per station and worker) and variance (with the standard deviation)")
+	flag.StringVar(&minTemp, "min_temp", "", "Lowest temperature aggregated by solution5 and solution6, lower ones are dropped")
+	flag.StringVar(&maxTemp, "max_temp", "", "Highest temperature aggregated by solution5 and solution6, higher ones are dropped")
+	flag.StringVar(&outlierBelow, "outlier_below", "", "Count per station the temperatures below this one (solution5 and solution6)")
+	flag.StringVar(&outlierAbove, "outlier_above", "", "Count per station the temperatures above this one (solution5 and solution6)")
+	flag.Int64Var(&bucketWidth, "histogram", 0, fmt.Sprintf("Bucket width in tenths of a degree of the per-station histograms to write to -out (stdout by default) instead of the stats, in -format %s (bars by default, solution5 and solution6)", strings.Join(brc.HistogramFormats(), ", ")))
+	flag.StringVar(&stationNames, "station", "", "Comma separated names of the only stations to output, solution5 and solution6 skip the other ones while parsing")
+	flag.StringVar(&stationPrefixes, "station_prefix", "", "Comma separated name prefixes of the only stations to output")
+	flag.StringVar(&stationRegex, "station_regex", "", "Regular expression matching the names of the only stations to output")
+	flag.IntVar(&options.warmup, "warmup", 1, "Unmeasured runs of each solution before benchmarking it")
+	flag.IntVar(&options.runs, "runs", 5, "Measured runs of each solution when benchmarking")
+	flag.StringVar(&options.cache, "cache", cacheKeep, "Page cache between benchmark runs: keep, warm (read the file before each solution) or drop (before every run, needs root)")
+	flag.StringVar(&reportPath, "report", "", "Path to write a JSON report of the benchmark to")
+	flag.StringVar(&historyPath, "history", "", "Path of a file to append the benchmark results to, see the compare command")
 	flag.Parse()
 
 	if filePath == "" {
@@ -70,41 +161,195 @@ func main() {
 		os.Exit(1)
 	}
 
-	if cpuProfilePath != "" {
-		profileFile, err := os.Create(cpuProfilePath)
+	if ioMode != string(brc.IOBuffered) && ioMode != string(brc.IOMmap) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid io mode %q, should be %s or %s\n", ioMode, brc.IOBuffered, brc.IOMmap)
+		os.Exit(1)
+	}
+
+	switch brc.Policy(policy) {
+	case brc.PolicyStrict, brc.PolicySkip:
+	case brc.PolicyReport:
+		if rejectsPath == "" {
+			fmt.Fprintln(os.Stderr, "Error: '-policy=report' needs '-rejects'")
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Invalid policy %q, should be %s, %s or %s\n", policy, brc.PolicyStrict, brc.PolicySkip, brc.PolicyReport)
+		os.Exit(1)
+	}
+
+	if options.warmup < 0 || options.runs < 1 {
+		fmt.Fprintln(os.Stderr, "Error: '-warmup' should not be negative and '-runs' should be positive")
+		os.Exit(1)
+	}
+
+	if options.cache != cacheKeep && options.cache != cacheWarm && options.cache != cacheDrop {
+		fmt.Fprintf(os.Stderr, "Error: Invalid cache mode %q, should be %s, %s or %s\n", options.cache, cacheKeep, cacheWarm, cacheDrop)
+		os.Exit(1)
+	}
+
+	stats, err := brc.ParseStats(statsList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if stats.Outliers, err = parseRange(outlierBelow, outlierAbove); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Invalid '-outlier_below' or '-outlier_above': %v\n", err)
+		os.Exit(1)
+	}
+	temperatures, err := parseRange(minTemp, maxTemp)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Invalid '-min_temp' or '-max_temp': %v\n", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case bucketWidth < 0:
+		fmt.Fprintln(os.Stderr, "Error: '-histogram' should be a positive bucket width")
+		os.Exit(1)
+	case bucketWidth > 0:
+		explicit := map[string]bool{}
+		flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+		if !explicit["format"] {
+			format = "bars"
+		}
+		if !explicit["out"] {
+			outPath = "-" // the histograms are the only output
+		}
+		if !slices.Contains(brc.HistogramFormats(), format) {
+			fmt.Fprintf(os.Stderr, "Error: Invalid histogram format %q, should be one of %s\n", format, strings.Join(brc.HistogramFormats(), ", "))
+			os.Exit(1)
+		}
+		stats.Percentiles = true // the histograms are kept for the percentiles
+	default:
+		if _, ok := brc.LookupEncoder(format); !ok {
+			fmt.Fprintf(os.Stderr, "Error: Invalid format %q, should be one of %s\n", format, strings.Join(brc.EncoderFormats(), ", "))
+			os.Exit(1)
+		}
+	}
+
+	var stations brc.StationFilter
+	if stationNames != "" {
+		stations.Names = strings.Split(stationNames, ",")
+	}
+	if stationPrefixes != "" {
+		stations.Prefixes = strings.Split(stationPrefixes, ",")
+	}
+	if stationRegex != "" {
+		if stations.Regexp, err = regexp.Compile(stationRegex); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Invalid '-station_regex': %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	stopProfiles, err := startProfiles(profiles)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer func() {
+		if err := stopProfiles(); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	ctx := context.Background()
+	if workers < 1 || segmentSize < 1 {
+		fmt.Fprintln(os.Stderr, "Error: '-workers' and '-segment_size' should be positive")
+		os.Exit(1)
+	}
+
+	input := brc.Input{
+		FilePath:    filePath,
+		IO:          brc.IOMode(ioMode),
+		Policy:      brc.Policy(policy),
+		Workers:     workers,
+		SegmentSize: segmentSize,
+		Stats:       stats,
+		Stations:    stations,
+		Range:       temperatures,
+	}
+	if filePath == "-" {
+		input.FilePath, input.Reader = "", os.Stdin
+	}
+	closeRejects := func() error { return nil }
+	if input.Policy == brc.PolicyReport {
+		input.Rejects, closeRejects, err = openRejects(rejectsPath)
 		if err != nil {
 			log.Fatalln(err)
 		}
-		pprof.StartCPUProfile(profileFile)
-		defer pprof.StopCPUProfile()
 	}
+	solvers := brc.Solvers()
 
 	switch {
+	case solution == 0 && input.Reader != nil:
+		fmt.Fprintln(os.Stderr, "Error: Benchmarking reads the file several times, use '-solution' with '-file=-'")
+		os.Exit(1)
+	case solution == 0 && input.Policy == brc.PolicyReport:
+		fmt.Fprintln(os.Stderr, "Error: Benchmarking would report the malformed lines several times, use '-solution' with '-policy=report'")
+		os.Exit(1)
+	case solution == 0 && (input.Stats != (brc.Stats{}) || input.Range != nil):
+		fmt.Fprintln(os.Stderr, "Error: Only solution5 and solution6 compute '-stats', '-histogram' and the temperature filters and outliers, use '-solution' with them")
+		os.Exit(1)
 	case solution == 0:
-		err = benchmark(filePath)
+		report, err := benchmark(ctx, input, options)
+		if reportPath != "" && len(report.Solutions) > 0 {
+			if err := writeReport(reportPath, report); err != nil {
+				log.Fatalln(err)
+			}
+		}
+		if historyPath != "" && len(report.Solutions) > 0 {
+			if err := appendHistory(historyPath, report); err != nil {
+				log.Fatalln(err)
+			}
+		}
 		if err != nil {
 			log.Fatalln(err)
 		}
-	case solution < 1 || solution > len(solutions):
+	case solution < 1 || solution > len(solvers):
 		fmt.Fprintf(
 			os.Stderr,
 			"Error: Invalid solution, should be between 1 and %d\n",
-			len(solutions))
+			len(solvers))
 		os.Exit(1)
 	default:
+		measure := measureMemory()
 		start := time.Now()
-		var output bytes.Buffer
-		solFunc := solutions[solution-1]
-		err = solFunc(filePath, &output)
+		solver := solvers[solution-1]
+		result, err := solver.Solve(ctx, input)
+		// flush the lines rejected so far even when the solver failed
+		if closeErr := closeRejects(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("writing the rejected lines: %w", closeErr))
+		}
 		if err != nil {
 			log.Fatalln(err)
 		}
 		elapsed := time.Since(start)
+		memory := measure(1)
+
+		if result.Rejected > 0 {
+			fmt.Fprintf(os.Stderr, "Skipped %d malformed lines\n", result.Rejected)
+		}
 
+		if outPath != "" {
+			err = writeResult(outPath, func(output io.Writer) error {
+				if bucketWidth > 0 {
+					return brc.EncodeHistograms(output, format, result, bucketWidth)
+				}
+				return brc.Encode(output, format, result)
+			})
+			if err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		timingOutput := os.Stdout
+		if outPath == "-" {
+			timingOutput = os.Stderr // keep stdout parseable
+		}
 		fmt.Fprintf(
-			os.Stdout,
-			"Solution%d ran in %s\n",
-			solution, elapsed,
+			timingOutput,
+			"Solution%d ran in %s\n%v\n",
+			solution, elapsed, memory,
 		)
 	}
 }
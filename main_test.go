@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"1brc-go/brc"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		low, high string
+		want      *brc.TemperatureRange
+	}{
+		{"", "", nil},
+		{"-60", "60", &brc.TemperatureRange{Min: -600, Max: 600}},
+		{"-30", "", &brc.TemperatureRange{Min: -300, Max: math.MaxInt64}},
+		{"", "45.5", &brc.TemperatureRange{Min: math.MinInt64, Max: 455}},
+		{"12.3", "12.3", &brc.TemperatureRange{Min: 123, Max: 123}},
+		{"-1000", "1000", &brc.TemperatureRange{Min: -10000, Max: 10000}}, // clamped by the solvers
+		{"0.04", "0.05", &brc.TemperatureRange{Min: 0, Max: 1}},
+	}
+	for _, tt := range tests {
+		got, err := parseRange(tt.low, tt.high)
+		if err != nil {
+			t.Errorf("parseRange(%q, %q): %v", tt.low, tt.high, err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+			t.Errorf("parseRange(%q, %q) = %v, want %v", tt.low, tt.high, got, tt.want)
+		}
+	}
+
+	for _, bounds := range [][2]string{{"1", "0"}, {"abc", ""}, {"", "1,5"}, {"-59.9", "-60"}} {
+		if got, err := parseRange(bounds[0], bounds[1]); err == nil {
+			t.Errorf("parseRange(%q, %q) = %v, want an error", bounds[0], bounds[1], got)
+		}
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rejects.txt")
+	rejects, closeRejects, err := openRejects(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(rejects, "Hamburg;")
+	if err := closeRejects(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != "Hamburg;\n" {
+		t.Errorf("got %q, %v, want the rejected line", got, err)
+	}
+
+	// the lines are buffered, a failed write shows when closing
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full to fail writes")
+	}
+	rejects, closeRejects, err = openRejects("/dev/full")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(rejects, "Hamburg;")
+	if err := closeRejects(); err == nil {
+		t.Error("closing after a failed write: got no error")
+	}
+}
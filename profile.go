@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+)
+
+type profileOptions struct {
+	cpu, mem, block, mutex, trace string // output paths, "" to disable
+}
+
+// startProfiles starts the profiles and the execution trace requested in
+// options and returns a function that stops them and writes them out.
+func startProfiles(options profileOptions) (stop func() error, err error) {
+	var stops []func() error
+	stop = func() error {
+		var firstErr error
+		for i := len(stops) - 1; i >= 0; i-- {
+			if err := stops[i](); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+	defer func() {
+		if err != nil {
+			stop()
+		}
+	}()
+
+	if options.cpu != "" {
+		file, err := os.Create(options.cpu)
+		if err != nil {
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return nil, err
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			return file.Close()
+		})
+	}
+
+	if options.trace != "" {
+		file, err := os.Create(options.trace)
+		if err != nil {
+			return nil, err
+		}
+		if err := trace.Start(file); err != nil {
+			file.Close()
+			return nil, err
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			return file.Close()
+		})
+	}
+
+	if options.block != "" {
+		runtime.SetBlockProfileRate(1)
+		stops = append(stops, func() error { return writeProfile("block", options.block) })
+	}
+	if options.mutex != "" {
+		runtime.SetMutexProfileFraction(1)
+		stops = append(stops, func() error { return writeProfile("mutex", options.mutex) })
+	}
+	if options.mem != "" {
+		// allocs samples every allocation since the start, in use or not
+		stops = append(stops, func() error { return writeProfile("allocs", options.mem) })
+	}
+	return stop, nil
+}
+
+// writeProfile writes the named pprof profile to path.
+func writeProfile(name, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.Lookup(name).WriteTo(file, 0); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// memoryUsage is what runs of a solution cost in memory.
+type memoryUsage struct {
+	AllocBytes uint64 `json:"alloc_bytes"` // allocated per run
+	Allocs     uint64 `json:"allocs"`      // heap objects allocated per run
+	GCCycles   uint32 `json:"gc_cycles"`   // in all runs
+	PeakRSS    uint64 `json:"peak_rss"`    // in bytes, 0 if unknown
+}
+
+// measureMemory starts measuring the memory used by the following runs and
+// returns a function that stops and returns it for the given number of runs.
+// The peak RSS is reset on linux, it is the process' peak elsewhere.
+func measureMemory() func(runs int) memoryUsage {
+	var before runtime.MemStats
+	runtime.GC() // don't charge the garbage of the previous runs
+	runtime.ReadMemStats(&before)
+	// 5 resets the peak RSS of the process, see proc(5)
+	os.WriteFile("/proc/self/clear_refs", []byte("5"), 0)
+
+	return func(runs int) memoryUsage {
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+		runs = max(runs, 1)
+		return memoryUsage{
+			AllocBytes: (after.TotalAlloc - before.TotalAlloc) / uint64(runs),
+			Allocs:     (after.Mallocs - before.Mallocs) / uint64(runs),
+			GCCycles:   after.NumGC - before.NumGC,
+			PeakRSS:    peakRSS(),
+		}
+	}
+}
+
+// peakRSS returns the VmHWM of the process from /proc/self/status, 0 if
+// unknown.
+func peakRSS() uint64 {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) == 3 && string(fields[0]) == "VmHWM:" {
+			kb, _ := strconv.ParseUint(string(fields[1]), 10, 64)
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+func (m memoryUsage) String() string {
+	rss := "unknown"
+	if m.PeakRSS > 0 {
+		rss = fmt.Sprintf("%.1fMB", float64(m.PeakRSS)/(1<<20))
+	}
+	return fmt.Sprintf("%.1fMB in %d allocations per run, %d GC cycles, peak RSS %s",
+		float64(m.AllocBytes)/(1<<20), m.Allocs, m.GCCycles, rss)
+}